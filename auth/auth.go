@@ -1,7 +1,6 @@
 package auth
 
 import (
-	"fmt"
 	"net/http"
 	"time"
 	
@@ -20,12 +19,28 @@ type Manager interface {
 	User() UserManager
 	CustomUser(id int, email string) UserManager
 	Manager() UserManager
+	Oidc(provider string) OidcManager
+	Email() EmailManager
+	Token() TokenManager
 	
+	Can(permission string) bool
 	In(email, password string) (In, error)
 	Out() error
+	Impersonate(userId int) error
+	StopImpersonating() error
+	Impersonations(userId int) ([]ImpersonationRecord, error)
+	Events(filter EventFilter) ([]Event, error)
+	Remember() error
+	Forget() error
 	
 	MustIn(email, password string) In
 	MustOut()
+	MustImpersonate(userId int)
+	MustStopImpersonating()
+	MustImpersonations(userId int) []ImpersonationRecord
+	MustEvents(filter EventFilter) []Event
+	MustRemember()
+	MustForget()
 }
 
 type In struct {
@@ -61,20 +76,33 @@ func New(
 	}
 }
 
+func (m *manager) Can(permission string) bool {
+	session, err := m.Session().Get()
+	if err != nil {
+		return false
+	}
+	return session.Can(permission)
+}
+
 func (m *manager) In(email, password string) (In, error) {
-	var r User
-	err := quirk.New(m.db).
-		Q(fmt.Sprintf(`SELECT id, email, roles, password, tfa FROM %s`, usersTable)).
-		Q("WHERE email = @email", quirk.Map{"email": email}).
-		Q("AND active = true").
-		Exec(&r)
+	throttle := m.throttle()
+	subjects := []throttleSubject{throttle.email(email), throttle.ip(getRequestIp(m.req, m.config.TrustedProxies))}
+	if err := throttle.check(subjects...); err != nil {
+		m.loginFailed(0, email, err)
+		return In{}, err
+	}
+	r, err := m.userStore().Get(0, email)
 	if err != nil {
 		return In{
 			Ok:  false,
 			Tfa: false,
 		}, err
 	}
-	if r.Id == 0 {
+	if r.Id == 0 || !r.Active {
+		if err := throttle.fail(subjects...); err != nil {
+			return In{}, err
+		}
+		m.loginFailed(r.Id, email, ErrorInvalidCredentials)
 		return In{
 			Ok:  false,
 			Tfa: false,
@@ -82,12 +110,38 @@ func (m *manager) In(email, password string) (In, error) {
 	}
 	ok, err := argon2.VerifyEncoded([]byte(password), []byte(r.Password))
 	if !ok || err != nil {
+		if err := throttle.fail(subjects...); err != nil {
+			return In{}, err
+		}
+		m.loginFailed(r.Id, email, ErrorInvalidCredentials)
 		return In{
 			Ok:  false,
 			Tfa: false,
 		}, err
 	}
-	if r.Tfa {
+	if err := throttle.reset(throttle.email(email)); err != nil {
+		return In{}, err
+	}
+	if err := m.rehashPassword(r, password); err != nil {
+		return In{}, err
+	}
+	if m.config.Email.Required && !r.EmailVerifiedAt.Valid {
+		m.loginFailed(r.Id, email, ErrorUnverifiedEmail)
+		return In{}, ErrorUnverifiedEmail
+	}
+	return m.signIn(r)
+}
+
+func (m *manager) MustIn(email, password string) In {
+	r, err := m.In(email, password)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (m *manager) signIn(r User) (In, error) {
+	if r.Tfa && !m.trustedDevice(r.Id) {
 		token := uniuri.New()
 		if err := m.cache.Set(createTfaCacheKey(token), User{Id: r.Id}, time.Minute*5); err != nil {
 			return In{}, err
@@ -99,7 +153,7 @@ func (m *manager) In(email, password string) (In, error) {
 			Tfa:   true,
 		}, nil
 	}
-	token, err := m.Session().New(r)
+	token, err := m.createSession(r)
 	if err != nil {
 		return In{}, err
 	}
@@ -110,16 +164,51 @@ func (m *manager) In(email, password string) (In, error) {
 	}, nil
 }
 
-func (m *manager) MustIn(email, password string) In {
-	r, err := m.In(email, password)
+func (m *manager) createSession(r User) (string, error) {
+	token, err := m.Session().New(r)
 	if err != nil {
-		panic(err)
+		return "", err
 	}
-	return r
+	m.emit(Event{Name: EventLoginSuccess, UserId: r.Id, Email: r.Email})
+	return token, nil
+}
+
+func (m *manager) rehashPassword(r User, password string) error {
+	policy := createPasswordPolicy(m.db, m.config.Password)
+	if !policy.outdated(r.Password) {
+		return nil
+	}
+	hash, err := policy.hash(password)
+	if err != nil {
+		return err
+	}
+	_, err = m.userStore().Update(r.Id, "", map[string]any{UserPassword: hash})
+	return err
+}
+
+func (m *manager) loginFailed(userId int, email string, reason error) {
+	m.emit(Event{Name: EventLoginFailure, UserId: userId, Email: email, Detail: reason.Error()})
 }
 
 func (m *manager) Out() error {
-	return m.Session().Destroy()
+	if m.db != nil {
+		if err := m.Forget(); err != nil {
+			return err
+		}
+	}
+	sm := m.Session()
+	session, err := sm.Get()
+	if err != nil {
+		return sm.Destroy()
+	}
+	if err := sm.Destroy(); err != nil {
+		return err
+	}
+	if session.Id == 0 {
+		return nil
+	}
+	m.emit(Event{Name: EventLogout, UserId: session.Id, Email: session.Email})
+	return nil
 }
 
 func (m *manager) MustOut() {
@@ -130,13 +219,32 @@ func (m *manager) MustOut() {
 }
 
 func (m *manager) Session() SessionManager {
-	return createSessionManager(
+	if m.config.Mode == SessionModeJwt {
+		s := createStatelessSessionManager(
+			m.req,
+			m.res,
+			m.cookie,
+			m.cache,
+			m.config,
+		).(*statelessSessionManager)
+		if m.db != nil {
+			s.tokens, s.restore, s.forget = m.Token(), m.restore, m.forgetAll
+		}
+		s.emit = m.emit
+		return s
+	}
+	s := createSessionManager(
 		m.req,
 		m.res,
 		m.cookie,
 		m.cache,
 		m.config,
-	)
+	).(*sessionManager)
+	if m.db != nil {
+		s.tokens, s.restore, s.forget = m.Token(), m.restore, m.forgetAll
+	}
+	s.emit = m.emit
+	return s
 }
 
 func (m *manager) Tfa() TfaManager {
@@ -145,13 +253,46 @@ func (m *manager) Tfa() TfaManager {
 
 func (m *manager) User() UserManager {
 	session := m.Session().MustGet()
-	return CreateUserManager(m.db, m.cache, session.Id, session.Email)
+	return m.createUserManager(session.Id, session.Email)
 }
 
 func (m *manager) CustomUser(id int, email string) UserManager {
-	return CreateUserManager(m.db, m.cache, id, email)
+	return m.createUserManager(id, email)
 }
 
 func (m *manager) Manager() UserManager {
 	return m.CustomUser(0, "")
 }
+
+func (m *manager) Oidc(provider string) OidcManager {
+	return createOidcManager(m, provider)
+}
+
+func (m *manager) Email() EmailManager {
+	return createEmailManager(m)
+}
+
+func (m *manager) Token() TokenManager {
+	return createTokenManager(m.db, m.config)
+}
+
+func (m *manager) userStore() UserStore {
+	if m.config.UserStore != nil {
+		return m.config.UserStore
+	}
+	return CreateUserStore(m.db, m.config.UserSchema)
+}
+
+func (m *manager) throttle() throttle {
+	return createThrottle(m.cache, m.config.Throttle)
+}
+
+func (m *manager) createUserManager(id int, email string) UserManager {
+	u := CreateCustomUserManager(m.userStore(), m.cache, id, email).(*userManager)
+	u.session = m.Session()
+	u.policy = createPasswordPolicy(m.db, m.config.Password)
+	u.revoke = m.config.RevokeSessionsOnPasswordUpdate
+	u.emit = m.emit
+	u.forget = m.forget
+	return u
+}
@@ -19,8 +19,8 @@ import (
 func TestAuth(t *testing.T) {
 	var sessionCookie, tfaCookie *http.Cookie
 	db, redis := createTestDatabaseConnection(t), createTestRedisConnection(t)
-	assert.NoError(t, DropTable(quirk.New(db)))
-	assert.NoError(t, CreateTable(quirk.New(db)))
+	assert.NoError(t, DropTable(db))
+	assert.NoError(t, CreateTable(db))
 	user := User{
 		Active:   true,
 		Roles:    []string{"owner"},
@@ -39,7 +39,7 @@ func TestAuth(t *testing.T) {
 	}
 	t.Cleanup(
 		func() {
-			assert.NoError(t, DropTable(quirk.New(db)))
+			assert.NoError(t, DropTable(db))
 		},
 	)
 	t.Run(
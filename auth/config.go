@@ -1,8 +1,37 @@
 package auth
 
-import "time"
+import (
+	"time"
+	
+	"github.com/daarlabs/arcanum/cache"
+)
 
 type Config struct {
-	Roles    []Role        `json:"roles" yaml:"roles" toml:"roles"`
-	Duration time.Duration `json:"duration" yaml:"duration" toml:"duration"`
+	Roles       []Role        `json:"roles" yaml:"roles" toml:"roles"`
+	Duration    time.Duration `json:"duration" yaml:"duration" toml:"duration"`
+	MaxLifetime time.Duration `json:"maxLifetime" yaml:"maxLifetime" toml:"maxLifetime"`
+	RememberMe  RememberMe    `json:"rememberMe" yaml:"rememberMe" toml:"rememberMe"`
+	Mode        string        `json:"mode" yaml:"mode" toml:"mode"`
+	Stateless   Stateless     `json:"stateless" yaml:"stateless" toml:"stateless"`
+	Throttle    Throttle      `json:"throttle" yaml:"throttle" toml:"throttle"`
+	Tfa         Tfa           `json:"tfa" yaml:"tfa" toml:"tfa"`
+	Password    Password      `json:"password" yaml:"password" toml:"password"`
+	
+	Providers []Provider `json:"providers" yaml:"providers" toml:"providers"`
+	Email     Email      `json:"email" yaml:"email" toml:"email"`
+	
+	Impersonation Impersonation `json:"impersonation" yaml:"impersonation" toml:"impersonation"`
+	
+	TrustedProxies []string `json:"trustedProxies" yaml:"trustedProxies" toml:"trustedProxies"`
+	
+	UserSchema UserSchema `json:"userSchema" yaml:"userSchema" toml:"userSchema"`
+	UserStore  UserStore  `json:"-" yaml:"-" toml:"-"`
+	
+	LegacyCache cache.Client `json:"-" yaml:"-" toml:"-"`
+	Bearer      bool         `json:"-" yaml:"-" toml:"-"`
+	
+	StoreEvents bool              `json:"storeEvents" yaml:"storeEvents" toml:"storeEvents"`
+	OnEvent     func(event Event) `json:"-" yaml:"-" toml:"-"`
+	
+	RevokeSessionsOnPasswordUpdate bool `json:"revokeSessionsOnPasswordUpdate" yaml:"revokeSessionsOnPasswordUpdate" toml:"revokeSessionsOnPasswordUpdate"`
 }
@@ -0,0 +1,472 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	
+	"github.com/dchest/uniuri"
+	
+	"github.com/daarlabs/arcanum/gox"
+	"github.com/daarlabs/arcanum/mailer"
+	"github.com/daarlabs/arcanum/mjml"
+	"github.com/daarlabs/arcanum/quirk"
+	"github.com/daarlabs/arcanum/translator"
+)
+
+type EmailManager interface {
+	SendVerification(email string) error
+	Verify(token string) (User, error)
+	SendMagicLink(email string) error
+	MagicLink(token string) (In, error)
+	SendReset(email string) error
+	Reset(token, password string) error
+	
+	MustSendVerification(email string)
+	MustVerify(token string) User
+	MustSendMagicLink(email string)
+	MustMagicLink(token string) In
+	MustSendReset(email string)
+	MustReset(token, password string)
+}
+
+type Email struct {
+	Secret       string                           `json:"secret" yaml:"secret" toml:"secret"`
+	Url          string                           `json:"url" yaml:"url" toml:"url"`
+	From         string                           `json:"from" yaml:"from" toml:"from"`
+	Lang         string                           `json:"lang" yaml:"lang" toml:"lang"`
+	Required     bool                             `json:"required" yaml:"required" toml:"required"`
+	Verification EmailFlow                        `json:"verification" yaml:"verification" toml:"verification"`
+	MagicLink    EmailFlow                        `json:"magicLink" yaml:"magicLink" toml:"magicLink"`
+	Reset        EmailFlow                        `json:"reset" yaml:"reset" toml:"reset"`
+	Otp          EmailFlow                        `json:"otp" yaml:"otp" toml:"otp"`
+	Smtp         mailer.Config                    `json:"smtp" yaml:"smtp" toml:"smtp"`
+	Translator   translator.Translator            `json:"-" yaml:"-" toml:"-"`
+	Send         func(message EmailMessage) error `json:"-" yaml:"-" toml:"-"`
+}
+
+type EmailFlow struct {
+	Path       string        `json:"path" yaml:"path" toml:"path"`
+	Expiration time.Duration `json:"expiration" yaml:"expiration" toml:"expiration"`
+	Template   EmailTemplate `json:"-" yaml:"-" toml:"-"`
+}
+
+type EmailTemplate func(data EmailData) gox.Node
+
+type EmailData struct {
+	Flow       string
+	Email      string
+	Lang       string
+	Link       string
+	Code       string
+	Expiration time.Duration
+	Translate  func(key string, args ...map[string]any) string
+}
+
+type EmailMessage struct {
+	EmailData
+	From    string
+	Subject string
+	Token   string
+	Nodes   []gox.Node
+}
+
+type emailManager struct {
+	manager *manager
+	config  Email
+}
+
+type emailToken struct {
+	Flow       string `json:"f"`
+	Id         int    `json:"i"`
+	Email      string `json:"e"`
+	Nonce      string `json:"n"`
+	Expiration int64  `json:"x"`
+}
+
+const (
+	EmailFlowVerification = "verification"
+	EmailFlowMagicLink    = "magic-link"
+	EmailFlowReset        = "reset"
+	EmailFlowOtp          = "otp"
+)
+
+const (
+	DefaultVerificationExpiration = 24 * time.Hour
+	DefaultMagicLinkExpiration    = 15 * time.Minute
+	DefaultResetExpiration        = time.Hour
+	DefaultOtpExpiration          = 10 * time.Minute
+)
+
+const (
+	emailTokenParam       = "token"
+	emailTokenNonceLength = 32
+)
+
+var (
+	emailTranslates = map[string]string{
+		"auth.email.verification.subject": "Verify your email",
+		"auth.email.verification.title":   "Verify your email",
+		"auth.email.verification.text":    "Confirm that {{email}} is your email address. The link expires in {{expiration}}.",
+		"auth.email.verification.button":  "Verify email",
+		"auth.email.magic-link.subject":   "Your sign in link",
+		"auth.email.magic-link.title":     "Sign in",
+		"auth.email.magic-link.text":      "Use the link below to sign in as {{email}}. The link expires in {{expiration}}.",
+		"auth.email.magic-link.button":    "Sign in",
+		"auth.email.reset.subject":        "Reset your password",
+		"auth.email.reset.title":          "Reset your password",
+		"auth.email.reset.text":           "Use the link below to set a new password for {{email}}. The link expires in {{expiration}}.",
+		"auth.email.reset.button":         "Reset password",
+		"auth.email.otp.subject":          "Your verification code",
+		"auth.email.otp.title":            "Verification code",
+		"auth.email.otp.text":             "Use the code {{code}} to finish signing in as {{email}}. The code expires in {{expiration}}.",
+	}
+)
+
+func createEmailManager(manager *manager) EmailManager {
+	config := manager.config.Email
+	if config.Verification.Expiration == 0 {
+		config.Verification.Expiration = DefaultVerificationExpiration
+	}
+	if config.MagicLink.Expiration == 0 {
+		config.MagicLink.Expiration = DefaultMagicLinkExpiration
+	}
+	if config.Reset.Expiration == 0 {
+		config.Reset.Expiration = DefaultResetExpiration
+	}
+	if config.Otp.Expiration == 0 {
+		config.Otp.Expiration = DefaultOtpExpiration
+	}
+	if len(config.Verification.Path) == 0 {
+		config.Verification.Path = "/" + EmailFlowVerification
+	}
+	if len(config.MagicLink.Path) == 0 {
+		config.MagicLink.Path = "/" + EmailFlowMagicLink
+	}
+	if len(config.Reset.Path) == 0 {
+		config.Reset.Path = "/" + EmailFlowReset
+	}
+	return &emailManager{
+		manager: manager,
+		config:  config,
+	}
+}
+
+func (m *emailManager) SendVerification(email string) error {
+	user, err := m.manager.CustomUser(0, email).Get()
+	if err != nil {
+		return err
+	}
+	if user.Id == 0 {
+		return nil
+	}
+	return m.send(EmailFlowVerification, user)
+}
+
+func (m *emailManager) MustSendVerification(email string) {
+	if err := m.SendVerification(email); err != nil {
+		panic(err)
+	}
+}
+
+func (m *emailManager) Verify(token string) (User, error) {
+	t, err := m.consume(EmailFlowVerification, token)
+	if err != nil {
+		return User{}, err
+	}
+	_, err = m.manager.userStore().Update(
+		t.Id, "",
+		map[string]any{UserEmailVerifiedAt: quirk.Safe(quirk.CurrentTimestamp)},
+		map[string]any{UserEmail: t.Email},
+	)
+	if err != nil {
+		return User{}, err
+	}
+	return m.manager.CustomUser(t.Id, t.Email).Get()
+}
+
+func (m *emailManager) MustVerify(token string) User {
+	user, err := m.Verify(token)
+	if err != nil {
+		panic(err)
+	}
+	return user
+}
+
+func (m *emailManager) SendMagicLink(email string) error {
+	user, err := m.manager.CustomUser(0, email).Get()
+	if err != nil {
+		return err
+	}
+	if user.Id == 0 || !user.Active {
+		return nil
+	}
+	return m.send(EmailFlowMagicLink, user)
+}
+
+func (m *emailManager) MustSendMagicLink(email string) {
+	if err := m.SendMagicLink(email); err != nil {
+		panic(err)
+	}
+}
+
+func (m *emailManager) MagicLink(token string) (In, error) {
+	t, err := m.consume(EmailFlowMagicLink, token)
+	if err != nil {
+		return In{}, err
+	}
+	user, err := m.manager.CustomUser(t.Id, t.Email).Get()
+	if err != nil {
+		return In{}, err
+	}
+	if user.Id == 0 || !user.Active || user.Email != t.Email {
+		return In{}, ErrorInvalidUser
+	}
+	return m.manager.signIn(user)
+}
+
+func (m *emailManager) MustMagicLink(token string) In {
+	r, err := m.MagicLink(token)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (m *emailManager) SendReset(email string) error {
+	user, err := m.manager.CustomUser(0, email).Get()
+	if err != nil {
+		return err
+	}
+	if user.Id == 0 || !user.Active {
+		return nil
+	}
+	return m.send(EmailFlowReset, user)
+}
+
+func (m *emailManager) MustSendReset(email string) {
+	if err := m.SendReset(email); err != nil {
+		panic(err)
+	}
+}
+
+func (m *emailManager) Reset(token, password string) error {
+	t, err := m.consume(EmailFlowReset, token)
+	if err != nil {
+		return err
+	}
+	return m.manager.CustomUser(t.Id, t.Email).ForceUpdatePassword(password)
+}
+
+func (m *emailManager) MustReset(token, password string) {
+	if err := m.Reset(token, password); err != nil {
+		panic(err)
+	}
+}
+
+func (m *emailManager) send(flow string, user User) error {
+	token, err := m.createToken(flow, user)
+	if err != nil {
+		return err
+	}
+	return m.deliver(m.createMessage(flow, user, token))
+}
+
+func (m *emailManager) sendCode(user User, code string) error {
+	return m.deliver(m.createMessage(EmailFlowOtp, user, "", code))
+}
+
+func (m *emailManager) deliver(message EmailMessage) error {
+	if m.config.Send != nil {
+		return m.config.Send(message)
+	}
+	return mailer.New(m.config.Smtp).
+		From(message.From).
+		To(message.Email).
+		Subject(message.Subject).
+		Body(message.Nodes...).
+		Send()
+}
+
+func (m *emailManager) createMessage(flow string, user User, token string, code ...string) EmailMessage {
+	config := m.flow(flow)
+	data := EmailData{
+		Flow:       flow,
+		Email:      user.Email,
+		Lang:       user.Locale,
+		Expiration: config.Expiration,
+	}
+	if len(data.Lang) == 0 {
+		data.Lang = m.config.Lang
+	}
+	if len(token) > 0 {
+		data.Link = m.createLink(config.Path, token)
+	}
+	if len(code) > 0 {
+		data.Code = code[0]
+	}
+	data.Translate = func(key string, args ...map[string]any) string {
+		if len(args) == 0 {
+			args = []map[string]any{
+				{"email": data.Email, "link": data.Link, "code": data.Code, "expiration": data.Expiration},
+			}
+		}
+		return m.translate(data.Lang, key, args...)
+	}
+	template := config.Template
+	if template == nil {
+		template = emailTemplate
+	}
+	from := m.config.From
+	if len(from) == 0 {
+		from = m.config.Smtp.From
+	}
+	return EmailMessage{
+		EmailData: data,
+		From:      from,
+		Subject:   data.Translate(createEmailTranslateKey(flow, "subject")),
+		Token:     token,
+		Nodes:     []gox.Node{template(data)},
+	}
+}
+
+func (m *emailManager) createLink(path, token string) string {
+	delimiter := "?"
+	if strings.Contains(path, "?") {
+		delimiter = "&"
+	}
+	return strings.TrimSuffix(m.config.Url, "/") + path + delimiter + url.Values{emailTokenParam: {token}}.Encode()
+}
+
+func (m *emailManager) translate(lang, key string, args ...map[string]any) string {
+	if m.config.Translator != nil {
+		if r := m.config.Translator.Translate(lang, key, args...); r != key {
+			return r
+		}
+	}
+	r, ok := emailTranslates[key]
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return r
+	}
+	for k, v := range args[0] {
+		r = strings.ReplaceAll(r, fmt.Sprintf("{{%s}}", k), fmt.Sprint(v))
+	}
+	return r
+}
+
+func (m *emailManager) flow(flow string) EmailFlow {
+	switch flow {
+	case EmailFlowVerification:
+		return m.config.Verification
+	case EmailFlowMagicLink:
+		return m.config.MagicLink
+	case EmailFlowReset:
+		return m.config.Reset
+	case EmailFlowOtp:
+		return m.config.Otp
+	}
+	return EmailFlow{}
+}
+
+func (m *emailManager) createToken(flow string, user User) (string, error) {
+	if len(m.config.Secret) == 0 {
+		return "", ErrorMissingSecret
+	}
+	expiration := m.flow(flow).Expiration
+	t := emailToken{
+		Flow:       flow,
+		Id:         user.Id,
+		Email:      user.Email,
+		Nonce:      uniuri.NewLen(emailTokenNonceLength),
+		Expiration: time.Now().Add(expiration).Unix(),
+	}
+	payload, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	if err := m.manager.cache.Set(createEmailTokenCacheKey(flow, t.Nonce), t.Id, expiration); err != nil {
+		return "", err
+	}
+	value := base64.RawURLEncoding.EncodeToString(payload)
+	return value + "." + m.sign(value), nil
+}
+
+func (m *emailManager) consume(flow, token string) (emailToken, error) {
+	var t emailToken
+	if len(m.config.Secret) == 0 {
+		return t, ErrorMissingSecret
+	}
+	value, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(m.sign(value))) {
+		return t, ErrorInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return t, ErrorInvalidToken
+	}
+	if err := json.Unmarshal(payload, &t); err != nil {
+		return t, ErrorInvalidToken
+	}
+	if t.Flow != flow || t.Id == 0 || time.Now().Unix() > t.Expiration {
+		return t, ErrorInvalidToken
+	}
+	key := createEmailTokenCacheKey(flow, t.Nonce)
+	if !m.manager.cache.Exists(key) {
+		return t, ErrorInvalidToken
+	}
+	claimed, err := m.manager.cache.SetNX(
+		createEmailTokenClaimCacheKey(flow, t.Nonce), t.Id, time.Until(time.Unix(t.Expiration, 0))+time.Second,
+	)
+	if err != nil {
+		return t, err
+	}
+	if !claimed {
+		return t, ErrorInvalidToken
+	}
+	if err := m.manager.cache.Destroy(key); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+func (m *emailManager) sign(value string) string {
+	h := hmac.New(sha256.New, []byte(m.config.Secret))
+	h.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func emailTemplate(data EmailData) gox.Node {
+	key := func(part string) string {
+		return data.Translate(createEmailTranslateKey(data.Flow, part))
+	}
+	action := mjml.Button(mjml.Href(data.Link), gox.Text(key("button")))
+	if len(data.Link) == 0 {
+		action = mjml.Text(mjml.Align("center"), gox.Text(data.Code))
+	}
+	return mjml.Mjml(
+		mjml.Head(
+			mjml.Title(gox.Text(key("subject"))),
+		),
+		mjml.Body(
+			mjml.Section(
+				mjml.Column(
+					mjml.Text(gox.Text(key("title"))),
+					mjml.Text(gox.Text(key("text"))),
+					action,
+				),
+			),
+		),
+	)
+}
+
+func createEmailTranslateKey(flow, part string) string {
+	return fmt.Sprintf("auth.email.%s.%s", flow, part)
+}
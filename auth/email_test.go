@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	
+	"github.com/stretchr/testify/assert"
+	
+	"github.com/daarlabs/arcanum/cache"
+	"github.com/daarlabs/arcanum/cache/memory"
+	"github.com/daarlabs/arcanum/gox"
+	"github.com/daarlabs/arcanum/translator"
+)
+
+func TestEmail(t *testing.T) {
+	c := cache.New(context.Background(), memory.New(t.TempDir()), nil)
+	user := User{Id: 1, Email: "dominik@linduska.dev"}
+	createTestEmailManager := func(config Email) *emailManager {
+		return createEmailManager(&manager{cache: c, config: Config{Email: config}}).(*emailManager)
+	}
+	t.Run(
+		"token", func(t *testing.T) {
+			m := createTestEmailManager(Email{Secret: "secret"})
+			token, err := m.createToken(EmailFlowReset, user)
+			assert.NoError(t, err)
+			_, err = m.consume(EmailFlowMagicLink, token)
+			assert.ErrorIs(t, err, ErrorInvalidToken)
+			_, err = createTestEmailManager(Email{Secret: "other"}).consume(EmailFlowReset, token)
+			assert.ErrorIs(t, err, ErrorInvalidToken)
+			r, err := m.consume(EmailFlowReset, token)
+			assert.NoError(t, err)
+			assert.Equal(t, user.Id, r.Id)
+			assert.Equal(t, user.Email, r.Email)
+			_, err = m.consume(EmailFlowReset, token)
+			assert.ErrorIs(t, err, ErrorInvalidToken)
+		},
+	)
+	t.Run(
+		"concurrent consume", func(t *testing.T) {
+			m := createTestEmailManager(Email{Secret: "secret"})
+			token, err := m.createToken(EmailFlowMagicLink, user)
+			assert.NoError(t, err)
+			var consumed atomic.Int32
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := m.consume(EmailFlowMagicLink, token); err == nil {
+						consumed.Add(1)
+					}
+				}()
+			}
+			wg.Wait()
+			assert.Equal(t, int32(1), consumed.Load())
+		},
+	)
+	t.Run(
+		"send missing user", func(t *testing.T) {
+			sent := 0
+			config := Config{
+				UserStore: &testUserStore{users: make(map[int]User)},
+				Email: Email{
+					Secret: "secret",
+					Send: func(message EmailMessage) error {
+						sent++
+						return nil
+					},
+				},
+			}
+			m := createEmailManager(&manager{cache: c, config: config})
+			assert.NoError(t, m.SendVerification("missing@linduska.dev"))
+			assert.NoError(t, m.SendMagicLink("missing@linduska.dev"))
+			assert.NoError(t, m.SendReset("missing@linduska.dev"))
+			assert.Equal(t, 0, sent)
+		},
+	)
+	t.Run(
+		"expiration", func(t *testing.T) {
+			m := createTestEmailManager(Email{Secret: "secret", MagicLink: EmailFlow{Expiration: time.Second}})
+			token, err := m.createToken(EmailFlowMagicLink, user)
+			assert.NoError(t, err)
+			time.Sleep(2 * time.Second)
+			_, err = m.consume(EmailFlowMagicLink, token)
+			assert.ErrorIs(t, err, ErrorInvalidToken)
+		},
+	)
+	t.Run(
+		"missing secret", func(t *testing.T) {
+			_, err := createTestEmailManager(Email{}).createToken(EmailFlowVerification, user)
+			assert.ErrorIs(t, err, ErrorMissingSecret)
+		},
+	)
+	t.Run(
+		"message", func(t *testing.T) {
+			m := createTestEmailManager(Email{Secret: "secret", Url: "https://app.dev/auth/", From: "noreply@app.dev"})
+			message := m.createMessage(EmailFlowVerification, user, "token")
+			assert.Equal(t, "https://app.dev/auth/verification?token=token", message.Link)
+			assert.Equal(t, "Verify your email", message.Subject)
+			assert.Equal(t, "noreply@app.dev", message.From)
+			assert.True(t, strings.Contains(gox.Render(message.Nodes...), message.Link))
+		},
+	)
+	t.Run(
+		"template translate", func(t *testing.T) {
+			m := createTestEmailManager(
+				Email{
+					Lang:       "cs",
+					Translator: translator.New(translator.Config{}).Extend(
+						"cs", map[string]string{"auth.email.reset.subject": "Obnova hesla pro {{email}}"},
+					),
+					Reset: EmailFlow{
+						Template: func(data EmailData) gox.Node {
+							return gox.Text(data.Translate("auth.email.reset.text"))
+						},
+					},
+				},
+			)
+			message := m.createMessage(EmailFlowReset, user, "token")
+			assert.Equal(t, "Obnova hesla pro dominik@linduska.dev", message.Subject)
+			assert.Equal(
+				t, "Use the link below to set a new password for dominik@linduska.dev. The link expires in 1h0m0s.",
+				gox.Render(message.Nodes...),
+			)
+		},
+	)
+	t.Run(
+		"recipient locale", func(t *testing.T) {
+			m := createTestEmailManager(
+				Email{
+					Lang:       "en",
+					Translator: translator.New(translator.Config{}).Extend(
+						"cs", map[string]string{"auth.email.reset.subject": "Obnova hesla"},
+					),
+				},
+			)
+			message := m.createMessage(EmailFlowReset, User{Email: user.Email, Locale: "cs"}, "token")
+			assert.Equal(t, "cs", message.Lang)
+			assert.Equal(t, "Obnova hesla", message.Subject)
+			message = m.createMessage(EmailFlowReset, user, "token")
+			assert.Equal(t, "en", message.Lang)
+			assert.Equal(t, "Reset your password", message.Subject)
+		},
+	)
+}
@@ -3,13 +3,33 @@ package auth
 import "errors"
 
 var (
-	ErrorMissingSessionCookie = errors.New("session cookie does not exist")
-	ErrorMissingTfaCookie     = errors.New("tfa cookie does not exist")
-	ErrorCredentialsMismatch  = errors.New("client is not equal with session")
-	ErrorMissingUser          = errors.New("user doesn't exist")
-	ErrorMismatchPassword     = errors.New("passwords aren't equal")
-	ErrorUserAlreadyExists    = errors.New("user already exists")
-	ErrorInvalidUser          = errors.New("invalid user")
-	ErrorInvalidOtp           = errors.New("invalid otp")
-	ErrorInvalidCredentials   = errors.New("invalid credentials")
+	ErrorMissingSessionCookie   = errors.New("session cookie does not exist")
+	ErrorMissingTfaCookie       = errors.New("tfa cookie does not exist")
+	ErrorCredentialsMismatch    = errors.New("client is not equal with session")
+	ErrorMissingUser            = errors.New("user doesn't exist")
+	ErrorMismatchPassword       = errors.New("passwords aren't equal")
+	ErrorUserAlreadyExists      = errors.New("user already exists")
+	ErrorInvalidUser            = errors.New("invalid user")
+	ErrorInvalidOtp             = errors.New("invalid otp")
+	ErrorInvalidCredentials     = errors.New("invalid credentials")
+	ErrorTooManyAttempts        = errors.New("too many attempts")
+	ErrorMissingProvider        = errors.New("oidc provider doesn't exist")
+	ErrorInvalidState           = errors.New("invalid oidc state")
+	ErrorInvalidIssuer          = errors.New("invalid oidc issuer")
+	ErrorInvalidToken           = errors.New("invalid token")
+	ErrorOidcExchange           = errors.New("oidc code exchange failed")
+	ErrorUnverifiedEmail        = errors.New("email isn't verified")
+	ErrorMissingSecret          = errors.New("secret is missing")
+	ErrorSecretTooShort         = errors.New("secret is too short")
+	ErrorAlreadyImpersonating   = errors.New("session is already impersonating")
+	ErrorNotImpersonating       = errors.New("session isn't impersonating")
+	ErrorImpersonationForbidden = errors.New("impersonation is forbidden")
+	ErrorUnsupportedSessionMode = errors.New("session mode doesn't support this operation")
+	ErrorSessionExpired         = errors.New("session has expired")
+	ErrorPasswordTooShort       = errors.New("password is too short")
+	ErrorPasswordTooLong        = errors.New("password is too long")
+	ErrorPasswordCharacters     = errors.New("password doesn't contain required characters")
+	ErrorPasswordReused         = errors.New("password was used recently")
+	ErrorPasswordBreached       = errors.New("password was found in a data breach")
+	ErrorInvalidPasswordHash    = errors.New("invalid password hash")
 )
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	
+	"github.com/daarlabs/arcanum/quirk"
+)
+
+type Event struct {
+	Id        int       `json:"id"`
+	Name      string    `json:"name"`
+	UserId    int       `json:"userId"`
+	Email     string    `json:"email"`
+	Ip        string    `json:"ip"`
+	UserAgent string    `json:"userAgent"`
+	Detail    string    `json:"detail"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type EventFilter struct {
+	UserId int
+	Names  []string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+const (
+	EventLoginSuccess   = "login.success"
+	EventLoginFailure   = "login.failure"
+	EventLogout         = "logout"
+	EventTfaEnable      = "tfa.enable"
+	EventTfaDisable     = "tfa.disable"
+	EventPasswordChange = "password.change"
+	EventSessionRenew   = "session.renew"
+)
+
+const (
+	EventName      = "name"
+	EventUserId    = "user_id"
+	EventEmail     = "email"
+	EventIp        = "ip"
+	EventUserAgent = "user_agent"
+	EventDetail    = "detail"
+)
+
+const (
+	DefaultEventsLimit = 100
+)
+
+const (
+	eventsTable = "auth_events"
+)
+
+func (m *manager) Events(filter EventFilter) ([]Event, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultEventsLimit
+	}
+	names, placeholders := quirk.Map{}, make([]string, len(filter.Names))
+	for i, name := range filter.Names {
+		key := fmt.Sprintf("%s%d", EventName, i)
+		names[key], placeholders[i] = name, paramPrefix+key
+	}
+	r := make([]Event, 0)
+	err := quirk.New(m.db).
+		Q(
+			fmt.Sprintf(
+				`SELECT id, %s, %s, %s, %s, %s, %s, %s FROM %s`,
+				EventName, EventUserId, EventEmail, EventIp, EventUserAgent, EventDetail, quirk.CreatedAt,
+				eventsTable,
+			),
+		).
+		Q(`WHERE 1 = 1`).
+		If(filter.UserId > 0, `AND user_id = @user_id`, quirk.Map{EventUserId: filter.UserId}).
+		If(len(filter.Names) > 0, fmt.Sprintf(`AND name IN (%s)`, strings.Join(placeholders, ", ")), names).
+		If(!filter.Since.IsZero(), `AND created_at >= @since`, quirk.Map{"since": filter.Since}).
+		If(!filter.Until.IsZero(), `AND created_at < @until`, quirk.Map{"until": filter.Until}).
+		Q(`ORDER BY id DESC`).
+		Q(fmt.Sprintf(`LIMIT %d`, filter.Limit)).
+		Exec(&r)
+	return r, err
+}
+
+func (m *manager) MustEvents(filter EventFilter) []Event {
+	r, err := m.Events(filter)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (m *manager) emit(event Event) {
+	event.Ip = getRequestIp(m.req, m.config.TrustedProxies)
+	event.UserAgent = m.req.Header.Get("User-Agent")
+	event.CreatedAt = time.Now()
+	if m.config.OnEvent != nil {
+		m.config.OnEvent(event)
+	}
+	if !m.config.StoreEvents || m.db == nil {
+		return
+	}
+	err := quirk.New(m.db).Q(fmt.Sprintf(`INSERT INTO %s`, eventsTable)).
+		Q(fmt.Sprintf(`(%s, %s, %s, %s, %s, %s)`, EventName, EventUserId, EventEmail, EventIp, EventUserAgent, EventDetail)).
+		Q(
+			`VALUES (@name, @user_id, @email, @ip, @user_agent, @detail)`,
+			quirk.Map{
+				EventName:      event.Name,
+				EventUserId:    event.UserId,
+				EventEmail:     event.Email,
+				EventIp:        event.Ip,
+				EventUserAgent: event.UserAgent,
+				EventDetail:    event.Detail,
+			},
+		).
+		Exec()
+	if err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	
+	"github.com/daarlabs/arcanum/cache"
+	"github.com/daarlabs/arcanum/cache/memory"
+	"github.com/daarlabs/arcanum/cookie"
+	"github.com/daarlabs/arcanum/quirk"
+)
+
+func TestEvent(t *testing.T) {
+	store := &testUserStore{users: make(map[int]User)}
+	userId := CreateCustomUserManager(store, nil, 0, "").MustCreate(
+		User{Active: true, Email: "dominik@linduska.dev", Password: "123456789"},
+	)
+	c := cache.New(context.Background(), memory.New(t.TempDir()), nil)
+	events := make([]Event, 0)
+	config := Config{
+		UserStore:      store,
+		Throttle:       Throttle{Disabled: true},
+		TrustedProxies: []string{"192.0.2.0/24"},
+		OnEvent: func(event Event) {
+			events = append(events, event)
+		},
+	}
+	createTestManager := func(token string) Manager {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("User-Agent", "desktop")
+		req.Header.Set("X-Forwarded-For", "10.0.0.1")
+		if len(token) > 0 {
+			req.AddCookie(&http.Cookie{Name: SessionCookieKey, Value: token})
+		}
+		res := httptest.NewRecorder()
+		return New(nil, req, res, cookie.New(req, res, "/"), c, config)
+	}
+	t.Run(
+		"login", func(t *testing.T) {
+			in := createTestManager("").MustIn("dominik@linduska.dev", "invalid")
+			assert.False(t, in.Ok)
+			assert.Equal(t, EventLoginFailure, events[0].Name)
+			assert.Equal(t, userId, events[0].UserId)
+			assert.Equal(t, "10.0.0.1", events[0].Ip)
+			assert.Equal(t, "desktop", events[0].UserAgent)
+			assert.Equal(t, ErrorInvalidCredentials.Error(), events[0].Detail)
+			in = createTestManager("").MustIn("dominik@linduska.dev", "123456789")
+			assert.Equal(t, EventLoginSuccess, events[1].Name)
+			m := createTestManager(in.Token)
+			m.Session().MustRenew()
+			assert.Equal(t, EventSessionRenew, events[2].Name)
+			m.User().MustUpdatePassword("123456789", "987654321")
+			assert.Equal(t, EventPasswordChange, events[3].Name)
+			assert.Equal(t, userId, events[3].UserId)
+			m.MustOut()
+			assert.Equal(t, EventLogout, events[4].Name)
+			assert.Len(t, events, 5)
+		},
+	)
+	t.Run(
+		"store failure", func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			mock.ExpectQuery(`INSERT INTO auth_events`).WillReturnError(errors.New("audit unavailable"))
+			config := config
+			config.StoreEvents = true
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			res := httptest.NewRecorder()
+			m := New(quirk.Wrap(conn, quirk.Mysql), req, res, cookie.New(req, res, "/"), c, config)
+			in, err := m.In("dominik@linduska.dev", "987654321")
+			assert.NoError(t, err)
+			assert.True(t, in.Ok)
+			assert.NotEmpty(t, in.Token)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		},
+	)
+	t.Run(
+		"filter names", func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			mock.ExpectQuery(`WHERE 1 = 1 AND name IN \(\?, \?\) ORDER BY id DESC LIMIT 100`).
+				WithArgs(EventLoginSuccess, EventLogout).
+				WillReturnRows(
+					sqlmock.NewRows([]string{"id", EventName, EventUserId, EventEmail, EventIp, EventUserAgent, EventDetail, quirk.CreatedAt}).
+						AddRow(1, EventLogout, userId, "dominik@linduska.dev", "", "", "", time.Now()),
+				)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			res := httptest.NewRecorder()
+			m := New(quirk.Wrap(conn, quirk.Mysql), req, res, cookie.New(req, res, "/"), c, config)
+			r, err := m.Events(EventFilter{Names: []string{EventLoginSuccess, EventLogout}})
+			assert.NoError(t, err)
+			assert.Len(t, r, 1)
+			assert.Equal(t, EventLogout, r[0].Name)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		},
+	)
+}
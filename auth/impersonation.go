@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+	
+	"github.com/daarlabs/arcanum/quirk"
+)
+
+type Impersonation struct {
+	Permission string `json:"permission" yaml:"permission" toml:"permission"`
+}
+
+type ImpersonationRecord struct {
+	Id             int                 `json:"id"`
+	ImpersonatorId int                 `json:"impersonatorId"`
+	UserId         int                 `json:"userId"`
+	Ip             string              `json:"ip"`
+	UserAgent      string              `json:"userAgent"`
+	StartedAt      time.Time           `json:"startedAt"`
+	StoppedAt      sql.Null[time.Time] `json:"stoppedAt"`
+}
+
+const (
+	ImpersonationImpersonatorId = "impersonator_id"
+	ImpersonationUserId         = "user_id"
+	ImpersonationIp             = "ip"
+	ImpersonationUserAgent      = "user_agent"
+	ImpersonationStartedAt      = "started_at"
+	ImpersonationStoppedAt      = "stopped_at"
+)
+
+const (
+	DefaultImpersonatePermission = "auth.impersonate"
+)
+
+const (
+	impersonationsTable = "user_impersonations"
+)
+
+func (m *manager) Impersonate(userId int) error {
+	sm, ok := m.Session().(*sessionManager)
+	if !ok {
+		return ErrorUnsupportedSessionMode
+	}
+	current, err := sm.Get()
+	if err != nil {
+		return err
+	}
+	if current.Id == 0 || len(current.Token) == 0 {
+		return ErrorMissingSessionCookie
+	}
+	if current.Impersonated() {
+		return ErrorAlreadyImpersonating
+	}
+	permission := m.config.Impersonation.Permission
+	if len(permission) == 0 {
+		permission = DefaultImpersonatePermission
+	}
+	if !current.Can(permission) || current.Id == userId {
+		return ErrorImpersonationForbidden
+	}
+	user, err := m.CustomUser(userId, "").Get()
+	if err != nil {
+		return err
+	}
+	if user.Id == 0 || !user.Active {
+		return ErrorInvalidUser
+	}
+	if containsSuperRole(m.config.Roles, user.Roles...) && !current.Super {
+		return ErrorImpersonationForbidden
+	}
+	if slices.ContainsFunc(
+		createPermissions(m.config.Roles, user.Roles...), func(permission string) bool {
+			return !current.Can(permission)
+		},
+	) {
+		return ErrorImpersonationForbidden
+	}
+	id, err := m.createImpersonation(current.Id, user.Id, sm.getUserAgent())
+	if err != nil {
+		return err
+	}
+	return sm.impersonate(user, current, id)
+}
+
+func (m *manager) MustImpersonate(userId int) {
+	if err := m.Impersonate(userId); err != nil {
+		panic(err)
+	}
+}
+
+func (m *manager) StopImpersonating() error {
+	sm, ok := m.Session().(*sessionManager)
+	if !ok {
+		return ErrorUnsupportedSessionMode
+	}
+	current, err := sm.Get()
+	if err != nil {
+		return err
+	}
+	if !current.Impersonated() {
+		return ErrorNotImpersonating
+	}
+	err = quirk.New(m.db).
+		Q(fmt.Sprintf(`UPDATE %s SET %s = CURRENT_TIMESTAMP`, impersonationsTable, ImpersonationStoppedAt)).
+		Q(`WHERE id = @id AND stopped_at IS NULL`, quirk.Map{quirk.Id: current.ImpersonationId}).
+		Exec()
+	if err != nil {
+		return err
+	}
+	if err := sm.revoke(current.Token); err != nil {
+		return err
+	}
+	var token string
+	if err := sm.cache.Get(createImpersonationCacheKey(current.ImpersonationId), &token); err != nil {
+		return err
+	}
+	if err := sm.cache.Destroy(createImpersonationCacheKey(current.ImpersonationId)); err != nil {
+		return err
+	}
+	impersonator, err := sm.Get(token)
+	if err != nil || len(token) == 0 || impersonator.Id != current.ImpersonatorId {
+		sm.cookie.Set(SessionCookieKey, "", time.Millisecond)
+		return ErrorMissingUser
+	}
+	sm.cookie.Set(SessionCookieKey, token, sm.ttl(impersonator))
+	return nil
+}
+
+func (m *manager) MustStopImpersonating() {
+	if err := m.StopImpersonating(); err != nil {
+		panic(err)
+	}
+}
+
+func (m *manager) createImpersonation(impersonatorId, userId int, userAgent string) (int, error) {
+	var id int
+	q := quirk.New(m.db).Q(fmt.Sprintf(`INSERT INTO %s`, impersonationsTable)).
+		Q(
+			fmt.Sprintf(
+				`(%s, %s, %s, %s)`,
+				ImpersonationImpersonatorId, ImpersonationUserId, ImpersonationIp, ImpersonationUserAgent,
+			),
+		).
+		Q(
+			`VALUES (@impersonator_id, @user_id, @ip, @user_agent)`,
+			quirk.Map{
+				ImpersonationImpersonatorId: impersonatorId,
+				ImpersonationUserId:         userId,
+				ImpersonationIp:             getRequestIp(m.req, m.config.TrustedProxies),
+				ImpersonationUserAgent:      userAgent,
+			},
+		)
+	if m.db.DriverName() == quirk.Mysql {
+		r, err := q.Result()
+		if err != nil {
+			return id, err
+		}
+		n, err := r.LastInsertId()
+		return int(n), err
+	}
+	err := q.Q(`RETURNING id`).Exec(&id)
+	return id, err
+}
+
+func (m *manager) Impersonations(userId int) ([]ImpersonationRecord, error) {
+	r := make([]ImpersonationRecord, 0)
+	err := quirk.New(m.db).
+		Q(
+			fmt.Sprintf(
+				`SELECT id, %s, %s, %s, %s, %s, %s FROM %s`,
+				ImpersonationImpersonatorId, ImpersonationUserId, ImpersonationIp, ImpersonationUserAgent,
+				ImpersonationStartedAt, ImpersonationStoppedAt,
+				impersonationsTable,
+			),
+		).
+		Q(`WHERE user_id = @id OR impersonator_id = @id`, quirk.Map{quirk.Id: userId}).
+		Q(`ORDER BY id DESC`).
+		Exec(&r)
+	return r, err
+}
+
+func (m *manager) MustImpersonations(userId int) []ImpersonationRecord {
+	r, err := m.Impersonations(userId)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (s Session) Impersonated() bool {
+	return s.ImpersonatorId > 0
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	
+	"github.com/daarlabs/arcanum/cache"
+	"github.com/daarlabs/arcanum/cache/memory"
+	"github.com/daarlabs/arcanum/cookie"
+	"github.com/daarlabs/arcanum/quirk"
+)
+
+func TestImpersonation(t *testing.T) {
+	db := createTestDatabaseConnection(t)
+	assert.NoError(t, DropTable(db))
+	assert.NoError(t, CreateTable(db))
+	t.Cleanup(
+		func() {
+			assert.NoError(t, DropTable(db))
+		},
+	)
+	c := cache.New(context.Background(), memory.New(t.TempDir()), nil)
+	config := Config{
+		Roles: []Role{
+			{Name: "owner", Super: true},
+			{Name: "support", Securables: []string{DefaultImpersonatePermission}},
+			{Name: "customer"},
+		},
+	}
+	um := CreateUserManager(db, nil, 0, "")
+	supportId := um.MustCreate(User{Active: true, Roles: []string{"support"}, Email: "support@linduska.dev", Password: "123456789"})
+	customerId := CreateUserManager(db, nil, 0, "").MustCreate(
+		User{Active: true, Roles: []string{"customer"}, Email: "customer@linduska.dev", Password: "123456789"},
+	)
+	ownerId := CreateUserManager(db, nil, 0, "").MustCreate(
+		User{Active: true, Roles: []string{"owner"}, Email: "owner@linduska.dev", Password: "123456789"},
+	)
+	createTestManager := func(token string) (Manager, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if len(token) > 0 {
+			req.AddCookie(&http.Cookie{Name: SessionCookieKey, Value: token})
+		}
+		res := httptest.NewRecorder()
+		return New(db, req, res, cookie.New(req, res, "/"), c, config), res
+	}
+	findSessionCookie := func(res *httptest.ResponseRecorder) string {
+		for _, item := range res.Result().Cookies() {
+			if item.Name == SessionCookieKey {
+				return item.Value
+			}
+		}
+		return ""
+	}
+	m, _ := createTestManager("")
+	support := m.Session().MustNew(User{Id: supportId, Email: "support@linduska.dev", Roles: []string{"support"}})
+	t.Run(
+		"impersonate stop", func(t *testing.T) {
+			m, res := createTestManager(support)
+			m.MustImpersonate(customerId)
+			impersonated := findSessionCookie(res)
+			m, _ = createTestManager(impersonated)
+			session := m.Session().MustGet()
+			assert.Equal(t, customerId, session.Id)
+			assert.Equal(t, supportId, session.ImpersonatorId)
+			assert.True(t, session.Impersonated())
+			assert.ErrorIs(t, m.Impersonate(ownerId), ErrorAlreadyImpersonating)
+			m, res = createTestManager(impersonated)
+			m.MustStopImpersonating()
+			assert.Equal(t, support, findSessionCookie(res))
+			records := m.MustImpersonations(customerId)
+			assert.Len(t, records, 1)
+			assert.Equal(t, supportId, records[0].ImpersonatorId)
+			assert.True(t, records[0].StoppedAt.Valid)
+		},
+	)
+	t.Run(
+		"forbidden", func(t *testing.T) {
+			m, _ := createTestManager(support)
+			assert.ErrorIs(t, m.Impersonate(ownerId), ErrorImpersonationForbidden)
+			m, _ = createTestManager("")
+			customer := m.Session().MustNew(User{Id: customerId, Email: "customer@linduska.dev", Roles: []string{"customer"}})
+			m, _ = createTestManager(customer)
+			assert.ErrorIs(t, m.Impersonate(supportId), ErrorImpersonationForbidden)
+			assert.ErrorIs(t, m.StopImpersonating(), ErrorNotImpersonating)
+		},
+	)
+}
+
+func TestImpersonationMysql(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	db := quirk.Wrap(conn, quirk.Mysql)
+	c := cache.New(context.Background(), memory.New(t.TempDir()), nil)
+	supportId, accountantId, customerId := 1, 2, 3
+	store := &testUserStore{
+		users: map[int]User{
+			supportId:    {Id: supportId, Active: true, Roles: []string{"support"}, Email: "support@linduska.dev"},
+			accountantId: {Id: accountantId, Active: true, Roles: []string{"accountant"}, Email: "accountant@linduska.dev"},
+			customerId:   {Id: customerId, Active: true, Roles: []string{"customer"}, Email: "customer@linduska.dev"},
+		},
+	}
+	config := Config{
+		UserStore:   store,
+		MaxLifetime: time.Hour,
+		Roles: []Role{
+			{Name: "support", Securables: []string{DefaultImpersonatePermission, "invoice.view"}},
+			{Name: "accountant", Securables: []string{"invoice.*"}},
+			{Name: "customer", Securables: []string{"invoice.view"}},
+		},
+	}
+	createTestManager := func(token string) (Manager, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if len(token) > 0 {
+			req.AddCookie(&http.Cookie{Name: SessionCookieKey, Value: token})
+		}
+		res := httptest.NewRecorder()
+		return New(db, req, res, cookie.New(req, res, "/"), c, config), res
+	}
+	findSessionCookie := func(res *httptest.ResponseRecorder) *http.Cookie {
+		for _, item := range res.Result().Cookies() {
+			if item.Name == SessionCookieKey {
+				return item
+			}
+		}
+		return nil
+	}
+	m, _ := createTestManager("")
+	support := m.Session().MustNew(User{Id: supportId, Email: "support@linduska.dev", Roles: []string{"support"}})
+	t.Run(
+		"permissions subset", func(t *testing.T) {
+			m, _ := createTestManager(support)
+			assert.ErrorIs(t, m.Impersonate(accountantId), ErrorImpersonationForbidden)
+		},
+	)
+	t.Run(
+		"impersonate stop", func(t *testing.T) {
+			mock.ExpectExec(`INSERT INTO user_impersonations .* VALUES \(\?, \?, \?, \?\)`).
+				WithArgs(supportId, customerId, sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(3, 1))
+			m, res := createTestManager(support)
+			m.MustImpersonate(customerId)
+			impersonated := findSessionCookie(res).Value
+			m, _ = createTestManager(impersonated)
+			session := m.Session().MustGet()
+			assert.Equal(t, 3, session.ImpersonationId)
+			encoded, err := json.Marshal(session)
+			assert.NoError(t, err)
+			assert.NotContains(t, string(encoded), support)
+			mock.ExpectQuery(`UPDATE user_impersonations SET stopped_at`).WithArgs(3).WillReturnRows(sqlmock.NewRows(nil))
+			m, res = createTestManager(impersonated)
+			m.MustStopImpersonating()
+			restored := findSessionCookie(res)
+			assert.Equal(t, support, restored.Value)
+			assert.True(t, restored.Expires.Before(time.Now().Add(time.Hour+time.Second)))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		},
+	)
+}
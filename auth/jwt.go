@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+)
+
+type jwt struct {
+	header    jwtHeader
+	payload   []byte
+	input     string
+	signature []byte
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type audience []string
+
+const (
+	jwtAlgRs256 = "RS256"
+	jwtAlgEs256 = "ES256"
+)
+
+func parseJwt(token string) (jwt, error) {
+	var r jwt
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return r, ErrorInvalidToken
+	}
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return r, ErrorInvalidToken
+	}
+	if err := json.Unmarshal(header, &r.header); err != nil {
+		return r, ErrorInvalidToken
+	}
+	r.payload, err = base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return r, ErrorInvalidToken
+	}
+	r.signature, err = base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return r, ErrorInvalidToken
+	}
+	r.input = parts[0] + "." + parts[1]
+	return r, nil
+}
+
+func (t jwt) verify(key crypto.PublicKey) error {
+	hash := sha256.Sum256([]byte(t.input))
+	switch t.header.Alg {
+	case jwtAlgRs256:
+		k, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(k, crypto.SHA256, hash[:], t.signature) != nil {
+			return ErrorInvalidToken
+		}
+		return nil
+	case jwtAlgEs256:
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(t.signature) != 64 {
+			return ErrorInvalidToken
+		}
+		r, s := new(big.Int).SetBytes(t.signature[:32]), new(big.Int).SetBytes(t.signature[32:])
+		if !ecdsa.Verify(k, hash[:], r, s) {
+			return ErrorInvalidToken
+		}
+		return nil
+	}
+	return ErrorInvalidToken
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, ErrorInvalidToken
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, ErrorInvalidToken
+}
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(b, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
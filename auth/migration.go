@@ -2,6 +2,7 @@ package auth
 
 import (
 	"fmt"
+	"slices"
 	
 	"github.com/daarlabs/arcanum/quirk"
 )
@@ -15,46 +16,495 @@ var (
 		{Name: UserPassword, Props: "varchar(128) not null"},
 		{Name: UserTfa, Props: "bool not null default false"},
 		{Name: UserTfaSecret, Props: "varchar(255)"},
-		{Name: UserTfaCodes, Props: "varchar(255)"},
+		{Name: UserTfaCodes, Props: "text"},
 		{Name: UserTfaUrl, Props: "varchar(255)"},
+		{Name: UserEmailVerifiedAt, Props: "timestamp"},
+		{Name: UserFirstName, Props: "varchar(255) not null default ''"},
+		{Name: UserLastName, Props: "varchar(255) not null default ''"},
+		{Name: UserLocale, Props: "varchar(16) not null default ''"},
+		{Name: UserTenantId, Props: "int not null default 0"},
 		{Name: quirk.Vectors, Props: "tsvector not null default ''"},
 		{Name: UserLastActivity, Props: "timestamp not null default current_timestamp"},
 		{Name: quirk.CreatedAt, Props: "timestamp not null default current_timestamp"},
 		{Name: quirk.UpdatedAt, Props: "timestamp not null default current_timestamp"},
 	}
+	pgTokenFields = []quirk.Field{
+		{Name: quirk.Id, Props: "serial primary key"},
+		{Name: TokenUserId, Props: "int not null"},
+		{Name: TokenName, Props: "varchar(255) not null"},
+		{Name: TokenHash, Props: "varchar(64) not null unique"},
+		{Name: TokenScopes, Props: "varchar[]"},
+		{Name: TokenExpiresAt, Props: "timestamp"},
+		{Name: TokenLastUsedAt, Props: "timestamp"},
+		{Name: TokenRevokedAt, Props: "timestamp"},
+		{Name: quirk.CreatedAt, Props: "timestamp not null default current_timestamp"},
+	}
+	pgImpersonationFields = []quirk.Field{
+		{Name: quirk.Id, Props: "serial primary key"},
+		{Name: ImpersonationImpersonatorId, Props: "int not null"},
+		{Name: ImpersonationUserId, Props: "int not null"},
+		{Name: ImpersonationIp, Props: "varchar(255) not null default ''"},
+		{Name: ImpersonationUserAgent, Props: "varchar(512) not null default ''"},
+		{Name: ImpersonationStartedAt, Props: "timestamp not null default current_timestamp"},
+		{Name: ImpersonationStoppedAt, Props: "timestamp"},
+	}
+	pgEventFields = []quirk.Field{
+		{Name: quirk.Id, Props: "serial primary key"},
+		{Name: EventName, Props: "varchar(64) not null"},
+		{Name: EventUserId, Props: "int not null default 0"},
+		{Name: EventEmail, Props: "varchar(255) not null default ''"},
+		{Name: EventIp, Props: "varchar(255) not null default ''"},
+		{Name: EventUserAgent, Props: "varchar(512) not null default ''"},
+		{Name: EventDetail, Props: "varchar(255) not null default ''"},
+		{Name: quirk.CreatedAt, Props: "timestamp not null default current_timestamp"},
+	}
+	pgRememberFields = []quirk.Field{
+		{Name: quirk.Id, Props: "serial primary key"},
+		{Name: RememberUserId, Props: "int not null"},
+		{Name: RememberSelector, Props: "varchar(32) not null unique"},
+		{Name: RememberHash, Props: "varchar(64) not null"},
+		{Name: RememberExpiresAt, Props: "timestamp not null"},
+		{Name: quirk.CreatedAt, Props: "timestamp not null default current_timestamp"},
+	}
+	pgIdentityFields = []quirk.Field{
+		{Name: quirk.Id, Props: "serial primary key"},
+		{Name: IdentityUserId, Props: "int not null"},
+		{Name: IdentityProvider, Props: "varchar(64) not null"},
+		{Name: IdentitySubject, Props: "varchar(255) not null"},
+		{Name: quirk.CreatedAt, Props: "timestamp not null default current_timestamp"},
+		{Name: "unique", Props: "(provider, subject)"},
+	}
+	pgPasswordHistoryFields = []quirk.Field{
+		{Name: quirk.Id, Props: "serial primary key"},
+		{Name: PasswordHistoryUserId, Props: "int not null"},
+		{Name: PasswordHistoryHash, Props: "varchar(128) not null"},
+		{Name: quirk.CreatedAt, Props: "timestamp not null default current_timestamp"},
+	}
+	mysqlUserFields = []quirk.Field{
+		{Name: quirk.Id, Props: "int auto_increment primary key"},
+		{Name: UserActive, Props: "bool not null default false"},
+		{Name: UserRoles, Props: "varchar(1024)"},
+		{Name: UserEmail, Props: "varchar(255) not null"},
+		{Name: UserPassword, Props: "varchar(128) not null"},
+		{Name: UserTfa, Props: "bool not null default false"},
+		{Name: UserTfaSecret, Props: "varchar(255)"},
+		{Name: UserTfaCodes, Props: "text"},
+		{Name: UserTfaUrl, Props: "varchar(255)"},
+		{Name: UserEmailVerifiedAt, Props: "timestamp null"},
+		{Name: UserFirstName, Props: "varchar(255) not null default ''"},
+		{Name: UserLastName, Props: "varchar(255) not null default ''"},
+		{Name: UserLocale, Props: "varchar(16) not null default ''"},
+		{Name: UserTenantId, Props: "int not null default 0"},
+		{Name: UserLastActivity, Props: "timestamp not null default current_timestamp"},
+		{Name: quirk.CreatedAt, Props: "timestamp not null default current_timestamp"},
+		{Name: quirk.UpdatedAt, Props: "timestamp not null default current_timestamp"},
+	}
+	mysqlTokenFields = []quirk.Field{
+		{Name: quirk.Id, Props: "int auto_increment primary key"},
+		{Name: TokenUserId, Props: "int not null"},
+		{Name: TokenName, Props: "varchar(255) not null"},
+		{Name: TokenHash, Props: "varchar(64) not null unique"},
+		{Name: TokenScopes, Props: "varchar(1024)"},
+		{Name: TokenExpiresAt, Props: "timestamp null"},
+		{Name: TokenLastUsedAt, Props: "timestamp null"},
+		{Name: TokenRevokedAt, Props: "timestamp null"},
+		{Name: quirk.CreatedAt, Props: "timestamp not null default current_timestamp"},
+	}
+	mysqlImpersonationFields = []quirk.Field{
+		{Name: quirk.Id, Props: "int auto_increment primary key"},
+		{Name: ImpersonationImpersonatorId, Props: "int not null"},
+		{Name: ImpersonationUserId, Props: "int not null"},
+		{Name: ImpersonationIp, Props: "varchar(255) not null default ''"},
+		{Name: ImpersonationUserAgent, Props: "varchar(512) not null default ''"},
+		{Name: ImpersonationStartedAt, Props: "timestamp not null default current_timestamp"},
+		{Name: ImpersonationStoppedAt, Props: "timestamp null"},
+	}
+	mysqlRememberFields = []quirk.Field{
+		{Name: quirk.Id, Props: "int auto_increment primary key"},
+		{Name: RememberUserId, Props: "int not null"},
+		{Name: RememberSelector, Props: "varchar(32) not null unique"},
+		{Name: RememberHash, Props: "varchar(64) not null"},
+		{Name: RememberExpiresAt, Props: "timestamp not null"},
+		{Name: quirk.CreatedAt, Props: "timestamp not null default current_timestamp"},
+	}
+	mysqlIdentityFields = []quirk.Field{
+		{Name: quirk.Id, Props: "int auto_increment primary key"},
+		{Name: IdentityUserId, Props: "int not null"},
+		{Name: IdentityProvider, Props: "varchar(64) not null"},
+		{Name: IdentitySubject, Props: "varchar(255) not null"},
+		{Name: quirk.CreatedAt, Props: "timestamp not null default current_timestamp"},
+		{Name: "unique", Props: "(provider, subject)"},
+	}
+	mysqlPasswordHistoryFields = []quirk.Field{
+		{Name: quirk.Id, Props: "int auto_increment primary key"},
+		{Name: PasswordHistoryUserId, Props: "int not null"},
+		{Name: PasswordHistoryHash, Props: "varchar(128) not null"},
+		{Name: quirk.CreatedAt, Props: "timestamp not null default current_timestamp"},
+	}
+	mysqlEventFields = []quirk.Field{
+		{Name: quirk.Id, Props: "int auto_increment primary key"},
+		{Name: EventName, Props: "varchar(64) not null"},
+		{Name: EventUserId, Props: "int not null default 0"},
+		{Name: EventEmail, Props: "varchar(255) not null default ''"},
+		{Name: EventIp, Props: "varchar(255) not null default ''"},
+		{Name: EventUserAgent, Props: "varchar(512) not null default ''"},
+		{Name: EventDetail, Props: "varchar(255) not null default ''"},
+		{Name: quirk.CreatedAt, Props: "timestamp not null default current_timestamp"},
+	}
 )
 
-func CreateTable(db *quirk.DB) error {
+func CreateTable(db *quirk.DB, schema ...UserSchema) error {
+	s := getUserSchema(schema...)
+	if err := db.Q(
+		fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s (%s)`,
+			s.table(),
+			quirk.CreateTableStructure(createUserFields(db, s)),
+		),
+	).Exec(); err != nil {
+		return err
+	}
+	if err := migrateUserTable(db, s); err != nil {
+		return err
+	}
+	if err := CreateTokenTable(db, s); err != nil {
+		return err
+	}
+	if err := CreateImpersonationTable(db); err != nil {
+		return err
+	}
+	if err := CreateRememberTable(db, s); err != nil {
+		return err
+	}
+	if err := CreatePasswordHistoryTable(db, s); err != nil {
+		return err
+	}
+	if err := CreateIdentityTable(db, s); err != nil {
+		return err
+	}
+	return CreateEventTable(db)
+}
+
+func MustCreateTable(q *quirk.DB, schema ...UserSchema) {
+	err := CreateTable(q, schema...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func DropTable(q *quirk.DB, schema ...UserSchema) error {
+	if err := DropEventTable(q); err != nil {
+		return err
+	}
+	if err := DropIdentityTable(q); err != nil {
+		return err
+	}
+	if err := DropPasswordHistoryTable(q); err != nil {
+		return err
+	}
+	if err := DropRememberTable(q); err != nil {
+		return err
+	}
+	if err := DropImpersonationTable(q); err != nil {
+		return err
+	}
+	if err := DropTokenTable(q); err != nil {
+		return err
+	}
+	return q.Q(fmt.Sprintf(`DROP TABLE IF EXISTS %s CASCADE`, getUserSchema(schema...).table())).Exec()
+}
+
+func MustDropTable(q *quirk.DB, schema ...UserSchema) {
+	err := DropTable(q, schema...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func CreateTokenTable(db *quirk.DB, schema ...UserSchema) error {
 	fields := make([]quirk.Field, 0)
 	switch db.DriverName() {
 	case quirk.Postgres:
-		for _, f := range pgUserFields {
+		for _, f := range referenceUserTable(pgTokenFields, TokenUserId, getUserSchema(schema...)) {
+			fields = append(fields, f)
+		}
+	case quirk.Mysql:
+		for _, f := range mysqlTokenFields {
 			fields = append(fields, f)
 		}
 	}
 	return db.Q(
 		fmt.Sprintf(
 			`CREATE TABLE IF NOT EXISTS %s (%s)`,
-			usersTable,
+			tokensTable,
 			quirk.CreateTableStructure(fields),
 		),
 	).Exec()
 }
 
-func MustCreateTable(q *quirk.DB) {
-	err := CreateTable(q)
-	if err != nil {
+func MustCreateTokenTable(db *quirk.DB, schema ...UserSchema) {
+	if err := CreateTokenTable(db, schema...); err != nil {
 		panic(err)
 	}
 }
 
-func DropTable(q *quirk.DB) error {
-	return q.Q(fmt.Sprintf(`DROP TABLE IF EXISTS %s CASCADE`, usersTable)).Exec()
+func DropTokenTable(q *quirk.DB) error {
+	return q.Q(fmt.Sprintf(`DROP TABLE IF EXISTS %s CASCADE`, tokensTable)).Exec()
 }
 
-func MustDropTable(q *quirk.DB) {
-	err := DropTable(q)
-	if err != nil {
+func MustDropTokenTable(q *quirk.DB) {
+	if err := DropTokenTable(q); err != nil {
+		panic(err)
+	}
+}
+
+func CreateImpersonationTable(db *quirk.DB) error {
+	fields := make([]quirk.Field, 0)
+	switch db.DriverName() {
+	case quirk.Postgres:
+		for _, f := range pgImpersonationFields {
+			fields = append(fields, f)
+		}
+	case quirk.Mysql:
+		for _, f := range mysqlImpersonationFields {
+			fields = append(fields, f)
+		}
+	}
+	return db.Q(
+		fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s (%s)`,
+			impersonationsTable,
+			quirk.CreateTableStructure(fields),
+		),
+	).Exec()
+}
+
+func MustCreateImpersonationTable(db *quirk.DB) {
+	if err := CreateImpersonationTable(db); err != nil {
+		panic(err)
+	}
+}
+
+func DropImpersonationTable(q *quirk.DB) error {
+	return q.Q(fmt.Sprintf(`DROP TABLE IF EXISTS %s CASCADE`, impersonationsTable)).Exec()
+}
+
+func MustDropImpersonationTable(q *quirk.DB) {
+	if err := DropImpersonationTable(q); err != nil {
+		panic(err)
+	}
+}
+
+func CreateEventTable(db *quirk.DB) error {
+	fields := make([]quirk.Field, 0)
+	switch db.DriverName() {
+	case quirk.Postgres:
+		for _, f := range pgEventFields {
+			fields = append(fields, f)
+		}
+	case quirk.Mysql:
+		for _, f := range mysqlEventFields {
+			fields = append(fields, f)
+		}
+	}
+	return db.Q(
+		fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s (%s)`,
+			eventsTable,
+			quirk.CreateTableStructure(fields),
+		),
+	).Exec()
+}
+
+func MustCreateEventTable(db *quirk.DB) {
+	if err := CreateEventTable(db); err != nil {
+		panic(err)
+	}
+}
+
+func DropEventTable(q *quirk.DB) error {
+	return q.Q(fmt.Sprintf(`DROP TABLE IF EXISTS %s CASCADE`, eventsTable)).Exec()
+}
+
+func MustDropEventTable(q *quirk.DB) {
+	if err := DropEventTable(q); err != nil {
+		panic(err)
+	}
+}
+
+func CreateRememberTable(db *quirk.DB, schema ...UserSchema) error {
+	fields := make([]quirk.Field, 0)
+	switch db.DriverName() {
+	case quirk.Postgres:
+		for _, f := range referenceUserTable(pgRememberFields, RememberUserId, getUserSchema(schema...)) {
+			fields = append(fields, f)
+		}
+	case quirk.Mysql:
+		for _, f := range mysqlRememberFields {
+			fields = append(fields, f)
+		}
+	}
+	return db.Q(
+		fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s (%s)`,
+			rememberTable,
+			quirk.CreateTableStructure(fields),
+		),
+	).Exec()
+}
+
+func MustCreateRememberTable(db *quirk.DB, schema ...UserSchema) {
+	if err := CreateRememberTable(db, schema...); err != nil {
+		panic(err)
+	}
+}
+
+func DropRememberTable(q *quirk.DB) error {
+	return q.Q(fmt.Sprintf(`DROP TABLE IF EXISTS %s CASCADE`, rememberTable)).Exec()
+}
+
+func MustDropRememberTable(q *quirk.DB) {
+	if err := DropRememberTable(q); err != nil {
+		panic(err)
+	}
+}
+
+func CreatePasswordHistoryTable(db *quirk.DB, schema ...UserSchema) error {
+	fields := make([]quirk.Field, 0)
+	switch db.DriverName() {
+	case quirk.Postgres:
+		for _, f := range referenceUserTable(pgPasswordHistoryFields, PasswordHistoryUserId, getUserSchema(schema...)) {
+			fields = append(fields, f)
+		}
+	case quirk.Mysql:
+		for _, f := range mysqlPasswordHistoryFields {
+			fields = append(fields, f)
+		}
+	}
+	return db.Q(
+		fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s (%s)`,
+			passwordHistoryTable,
+			quirk.CreateTableStructure(fields),
+		),
+	).Exec()
+}
+
+func MustCreatePasswordHistoryTable(db *quirk.DB, schema ...UserSchema) {
+	if err := CreatePasswordHistoryTable(db, schema...); err != nil {
 		panic(err)
 	}
 }
+
+func DropPasswordHistoryTable(q *quirk.DB) error {
+	return q.Q(fmt.Sprintf(`DROP TABLE IF EXISTS %s CASCADE`, passwordHistoryTable)).Exec()
+}
+
+func MustDropPasswordHistoryTable(q *quirk.DB) {
+	if err := DropPasswordHistoryTable(q); err != nil {
+		panic(err)
+	}
+}
+
+func CreateIdentityTable(db *quirk.DB, schema ...UserSchema) error {
+	fields := make([]quirk.Field, 0)
+	switch db.DriverName() {
+	case quirk.Postgres:
+		for _, f := range referenceUserTable(pgIdentityFields, IdentityUserId, getUserSchema(schema...)) {
+			fields = append(fields, f)
+		}
+	case quirk.Mysql:
+		for _, f := range mysqlIdentityFields {
+			fields = append(fields, f)
+		}
+	}
+	return db.Q(
+		fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s (%s)`,
+			identitiesTable,
+			quirk.CreateTableStructure(fields),
+		),
+	).Exec()
+}
+
+func MustCreateIdentityTable(db *quirk.DB, schema ...UserSchema) {
+	if err := CreateIdentityTable(db, schema...); err != nil {
+		panic(err)
+	}
+}
+
+func DropIdentityTable(q *quirk.DB) error {
+	return q.Q(fmt.Sprintf(`DROP TABLE IF EXISTS %s CASCADE`, identitiesTable)).Exec()
+}
+
+func MustDropIdentityTable(q *quirk.DB) {
+	if err := DropIdentityTable(q); err != nil {
+		panic(err)
+	}
+}
+
+func createUserFields(db *quirk.DB, schema UserSchema) []quirk.Field {
+	fields := make([]quirk.Field, 0)
+	source := make([]quirk.Field, 0)
+	switch db.DriverName() {
+	case quirk.Postgres:
+		source = pgUserFields
+	case quirk.Mysql:
+		source = mysqlUserFields
+	}
+	for _, f := range source {
+		if !schema.enabled(f.Name) {
+			continue
+		}
+		f.Name = schema.column(f.Name)
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func migrateUserTable(db *quirk.DB, schema UserSchema) error {
+	existing := make([]string, 0)
+	alter := `ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s`
+	if db.DriverName() == quirk.Mysql {
+		alter = `ALTER TABLE %s ADD COLUMN %s %s`
+		if err := db.Q(`SELECT column_name FROM information_schema.columns`).
+			Q(`WHERE table_schema = DATABASE() AND table_name = @table`, quirk.Map{"table": schema.table()}).
+			Exec(&existing); err != nil {
+			return err
+		}
+	}
+	for _, f := range createUserFields(db, schema) {
+		if f.Name == schema.column(quirk.Id) || slices.Contains(existing, f.Name) {
+			continue
+		}
+		if err := db.Q(fmt.Sprintf(alter, schema.table(), f.Name, f.Props)).Exec(); err != nil {
+			return err
+		}
+	}
+	if !schema.enabled(UserTfaCodes) {
+		return nil
+	}
+	widen := `ALTER TABLE %s ALTER COLUMN %s TYPE text`
+	if db.DriverName() == quirk.Mysql {
+		widen = `ALTER TABLE %s MODIFY COLUMN %s text`
+	}
+	return db.Q(fmt.Sprintf(widen, schema.table(), schema.column(UserTfaCodes))).Exec()
+}
+
+func getUserSchema(schema ...UserSchema) UserSchema {
+	if len(schema) == 0 {
+		return UserSchema{}
+	}
+	return schema[0]
+}
+
+func referenceUserTable(fields []quirk.Field, name string, schema UserSchema) []quirk.Field {
+	result := make([]quirk.Field, len(fields))
+	for i, f := range fields {
+		if f.Name == name {
+			f.Props += fmt.Sprintf(" references %s (%s) on delete cascade", schema.table(), schema.column(quirk.Id))
+		}
+		result[i] = f
+	}
+	return result
+}
@@ -0,0 +1,459 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	
+	"github.com/dchest/uniuri"
+	
+	"github.com/daarlabs/arcanum/cookie"
+	"github.com/daarlabs/arcanum/quirk"
+)
+
+type OidcManager interface {
+	Redirect() (string, error)
+	Identity() (Identity, error)
+	Callback() (In, error)
+	
+	MustRedirect() string
+	MustIdentity() Identity
+	MustCallback() In
+}
+
+type Provider struct {
+	Name                  string       `json:"name" yaml:"name" toml:"name"`
+	Issuer                string       `json:"issuer" yaml:"issuer" toml:"issuer"`
+	ClientId              string       `json:"clientId" yaml:"clientId" toml:"clientId"`
+	ClientSecret          string       `json:"clientSecret" yaml:"clientSecret" toml:"clientSecret"`
+	RedirectUrl           string       `json:"redirectUrl" yaml:"redirectUrl" toml:"redirectUrl"`
+	Scopes                []string     `json:"scopes" yaml:"scopes" toml:"scopes"`
+	AuthorizationEndpoint string       `json:"authorizationEndpoint" yaml:"authorizationEndpoint" toml:"authorizationEndpoint"`
+	TokenEndpoint         string       `json:"tokenEndpoint" yaml:"tokenEndpoint" toml:"tokenEndpoint"`
+	JwksUri               string       `json:"jwksUri" yaml:"jwksUri" toml:"jwksUri"`
+	AutoProvision         bool         `json:"autoProvision" yaml:"autoProvision" toml:"autoProvision"`
+	Roles                 []string     `json:"roles" yaml:"roles" toml:"roles"`
+	HttpClient            *http.Client `json:"-" yaml:"-" toml:"-"`
+}
+
+type Identity struct {
+	Provider      string `json:"provider"`
+	Subject       string `json:"subject"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"emailVerified"`
+	Name          string `json:"name"`
+}
+
+type oidcManager struct {
+	manager  *manager
+	provider Provider
+}
+
+type oidcKey struct {
+	Key       crypto.PublicKey
+	ExpiresAt time.Time
+}
+
+type oidcState struct {
+	Provider string `json:"provider"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+type oidcToken struct {
+	AccessToken string `json:"access_token"`
+	IdToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+	Error       string `json:"error"`
+}
+
+type oidcClaims struct {
+	Issuer        string   `json:"iss"`
+	Subject       string   `json:"sub"`
+	Audience      audience `json:"aud"`
+	Expiration    int64    `json:"exp"`
+	IssuedAt      int64    `json:"iat"`
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified any      `json:"email_verified"`
+	Name          string   `json:"name"`
+}
+
+const (
+	IdentityUserId   = "user_id"
+	IdentityProvider = "provider"
+	IdentitySubject  = "subject"
+)
+
+const (
+	OidcCookieKey = "X-Oidc"
+)
+
+const (
+	identitiesTable         = "user_identities"
+	oidcStateDuration       = 10 * time.Minute
+	oidcKeysDuration        = time.Hour
+	oidcKeysRefetchInterval = time.Minute
+	oidcLeeway              = time.Minute
+	oidcDiscoveryPath       = "/.well-known/openid-configuration"
+	oidcVerifierLength      = 64
+)
+
+var (
+	oidcDefaultScopes = []string{"openid", "email", "profile"}
+	oidcDiscoveries   sync.Map
+	oidcKeys          sync.Map
+	oidcKeysFetchedAt sync.Map
+)
+
+func createOidcManager(manager *manager, name string) OidcManager {
+	m := &oidcManager{manager: manager}
+	for _, p := range manager.config.Providers {
+		if p.Name == name {
+			m.provider = p
+		}
+	}
+	return m
+}
+
+func (m *oidcManager) Redirect() (string, error) {
+	discovery, err := m.discover()
+	if err != nil {
+		return "", err
+	}
+	state := uniuri.New()
+	s := oidcState{
+		Provider: m.provider.Name,
+		Nonce:    uniuri.New(),
+		Verifier: uniuri.NewLen(oidcVerifierLength),
+	}
+	if err := m.manager.cache.Set(createOidcCacheKey(state), s, oidcStateDuration); err != nil {
+		return "", err
+	}
+	m.manager.cookie.Set(OidcCookieKey, state, oidcStateDuration, cookie.SameSite(http.SameSiteLaxMode), cookie.HttpOnly())
+	challenge := sha256.Sum256([]byte(s.Verifier))
+	scopes := m.provider.Scopes
+	if len(scopes) == 0 {
+		scopes = oidcDefaultScopes
+	}
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {m.provider.ClientId},
+		"redirect_uri":          {m.provider.RedirectUrl},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {s.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	delimiter := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		delimiter = "&"
+	}
+	return discovery.AuthorizationEndpoint + delimiter + query.Encode(), nil
+}
+
+func (m *oidcManager) MustRedirect() string {
+	r, err := m.Redirect()
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (m *oidcManager) Identity() (Identity, error) {
+	discovery, err := m.discover()
+	if err != nil {
+		return Identity{}, err
+	}
+	query := m.manager.req.URL.Query()
+	if e := query.Get("error"); len(e) > 0 {
+		return Identity{}, fmt.Errorf("%w: %s", ErrorOidcExchange, e)
+	}
+	state := query.Get("state")
+	if len(state) == 0 || state != m.manager.cookie.Get(OidcCookieKey) {
+		return Identity{}, ErrorInvalidState
+	}
+	var s oidcState
+	if err := m.manager.cache.Get(createOidcCacheKey(state), &s); err != nil {
+		return Identity{}, err
+	}
+	if err := m.manager.cache.Destroy(createOidcCacheKey(state)); err != nil {
+		return Identity{}, err
+	}
+	m.manager.cookie.Destroy(OidcCookieKey)
+	if s.Provider != m.provider.Name || len(s.Verifier) == 0 {
+		return Identity{}, ErrorInvalidState
+	}
+	token, err := m.exchange(discovery, query.Get("code"), s.Verifier)
+	if err != nil {
+		return Identity{}, err
+	}
+	claims, err := m.verify(discovery, token.IdToken, s.Nonce)
+	if err != nil {
+		return Identity{}, err
+	}
+	return Identity{
+		Provider:      m.provider.Name,
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(claims.Email),
+		EmailVerified: claims.EmailVerified == true || claims.EmailVerified == "true",
+		Name:          claims.Name,
+	}, nil
+}
+
+func (m *oidcManager) MustIdentity() Identity {
+	r, err := m.Identity()
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (m *oidcManager) Callback() (In, error) {
+	identity, err := m.Identity()
+	if err != nil {
+		return In{}, err
+	}
+	user, err := m.linked(identity)
+	if err != nil {
+		return In{}, err
+	}
+	if user.Id == 0 {
+		user, err = m.link(identity)
+		if err != nil {
+			return In{}, err
+		}
+	}
+	if !user.Active {
+		return In{}, ErrorInvalidUser
+	}
+	return m.manager.signIn(user)
+}
+
+func (m *oidcManager) MustCallback() In {
+	r, err := m.Callback()
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (m *oidcManager) linked(identity Identity) (User, error) {
+	ids := make([]int, 0)
+	err := quirk.New(m.manager.db).
+		Q(fmt.Sprintf(`SELECT %s FROM %s`, IdentityUserId, identitiesTable)).
+		Q(
+			`WHERE provider = @provider AND subject = @subject`,
+			quirk.Map{IdentityProvider: identity.Provider, IdentitySubject: identity.Subject},
+		).
+		Q(`LIMIT 1`).
+		Exec(&ids)
+	if err != nil || len(ids) == 0 {
+		return User{}, err
+	}
+	return m.manager.CustomUser(ids[0], "").Get()
+}
+
+func (m *oidcManager) link(identity Identity) (User, error) {
+	if len(identity.Email) == 0 || !identity.EmailVerified {
+		return User{}, ErrorUnverifiedEmail
+	}
+	user, err := m.manager.CustomUser(0, identity.Email).Get()
+	if err != nil {
+		return user, err
+	}
+	if user.Id == 0 {
+		if !m.provider.AutoProvision {
+			return user, ErrorMissingUser
+		}
+		password, err := createPasswordPolicy(m.manager.db, m.manager.config.Password).hash(uniuri.NewLen(oidcVerifierLength))
+		if err != nil {
+			return user, err
+		}
+		user = User{
+			Active:   true,
+			Email:    identity.Email,
+			Password: password,
+			Roles:    m.provider.Roles,
+		}
+		user.Id, err = m.manager.CustomUser(0, "").Import(user)
+		if err != nil {
+			return user, err
+		}
+	}
+	return user, quirk.New(m.manager.db).Q(fmt.Sprintf(`INSERT INTO %s`, identitiesTable)).
+		Q(fmt.Sprintf(`(%s, %s, %s)`, IdentityUserId, IdentityProvider, IdentitySubject)).
+		Q(
+			`VALUES (@user_id, @provider, @subject)`,
+			quirk.Map{IdentityUserId: user.Id, IdentityProvider: identity.Provider, IdentitySubject: identity.Subject},
+		).
+		Exec()
+}
+
+func (m *oidcManager) discover() (oidcDiscovery, error) {
+	if len(m.provider.Name) == 0 {
+		return oidcDiscovery{}, ErrorMissingProvider
+	}
+	d := oidcDiscovery{
+		Issuer:                m.provider.Issuer,
+		AuthorizationEndpoint: m.provider.AuthorizationEndpoint,
+		TokenEndpoint:         m.provider.TokenEndpoint,
+		JwksUri:               m.provider.JwksUri,
+	}
+	if len(d.AuthorizationEndpoint) > 0 && len(d.TokenEndpoint) > 0 && len(d.JwksUri) > 0 {
+		return d, nil
+	}
+	if cached, ok := oidcDiscoveries.Load(m.provider.Issuer); ok {
+		return cached.(oidcDiscovery), nil
+	}
+	var r oidcDiscovery
+	if err := m.fetch(strings.TrimSuffix(m.provider.Issuer, "/")+oidcDiscoveryPath, &r); err != nil {
+		return r, err
+	}
+	if r.Issuer != m.provider.Issuer {
+		return r, ErrorInvalidIssuer
+	}
+	oidcDiscoveries.Store(m.provider.Issuer, r)
+	return r, nil
+}
+
+func (m *oidcManager) exchange(discovery oidcDiscovery, code string, verifier string) (oidcToken, error) {
+	var r oidcToken
+	if len(code) == 0 {
+		return r, ErrorOidcExchange
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {m.provider.RedirectUrl},
+		"client_id":     {m.provider.ClientId},
+		"client_secret": {m.provider.ClientSecret},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(
+		m.manager.req.Context(), http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return r, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	res, err := m.client().Do(req)
+	if err != nil {
+		return r, err
+	}
+	defer res.Body.Close()
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return r, err
+	}
+	if res.StatusCode != http.StatusOK || len(r.Error) > 0 || len(r.IdToken) == 0 {
+		return r, fmt.Errorf("%w: %s", ErrorOidcExchange, r.Error)
+	}
+	return r, nil
+}
+
+func (m *oidcManager) verify(discovery oidcDiscovery, token string, nonce string) (oidcClaims, error) {
+	var claims oidcClaims
+	t, err := parseJwt(token)
+	if err != nil {
+		return claims, err
+	}
+	key, err := m.key(discovery, t.header.Kid)
+	if err != nil {
+		return claims, err
+	}
+	if err := t.verify(key); err != nil {
+		return claims, err
+	}
+	if err := json.Unmarshal(t.payload, &claims); err != nil {
+		return claims, ErrorInvalidToken
+	}
+	now := time.Now()
+	switch {
+	case claims.Issuer != discovery.Issuer:
+		return claims, ErrorInvalidIssuer
+	case !slices.Contains(claims.Audience, m.provider.ClientId):
+		return claims, ErrorInvalidToken
+	case now.After(time.Unix(claims.Expiration, 0).Add(oidcLeeway)):
+		return claims, ErrorInvalidToken
+	case claims.IssuedAt > 0 && now.Add(oidcLeeway).Before(time.Unix(claims.IssuedAt, 0)):
+		return claims, ErrorInvalidToken
+	case claims.Nonce != nonce:
+		return claims, ErrorInvalidToken
+	}
+	return claims, nil
+}
+
+func (m *oidcManager) key(discovery oidcDiscovery, kid string) (crypto.PublicKey, error) {
+	id := discovery.JwksUri + "#" + kid
+	cached, ok := oidcKeys.Load(id)
+	if ok && time.Now().Before(cached.(oidcKey).ExpiresAt) {
+		return cached.(oidcKey).Key, nil
+	}
+	fetchedAt, fetched := oidcKeysFetchedAt.Load(discovery.JwksUri)
+	if fetched && time.Since(fetchedAt.(time.Time)) < oidcKeysRefetchInterval {
+		if ok {
+			return cached.(oidcKey).Key, nil
+		}
+		return nil, ErrorInvalidToken
+	}
+	oidcKeysFetchedAt.Store(discovery.JwksUri, time.Now())
+	var set jwks
+	if err := m.fetch(discovery.JwksUri, &set); err != nil {
+		return nil, err
+	}
+	for _, k := range set.Keys {
+		if len(k.Use) > 0 && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		oidcKeys.Store(discovery.JwksUri+"#"+k.Kid, oidcKey{Key: pub, ExpiresAt: time.Now().Add(oidcKeysDuration)})
+	}
+	if cached, ok := oidcKeys.Load(id); ok {
+		return cached.(oidcKey).Key, nil
+	}
+	return nil, ErrorInvalidToken
+}
+
+func (m *oidcManager) fetch(url string, data any) error {
+	req, err := http.NewRequestWithContext(m.manager.req.Context(), http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := m.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s", ErrorOidcExchange, res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(data)
+}
+
+func (m *oidcManager) client() *http.Client {
+	if m.provider.HttpClient != nil {
+		return m.provider.HttpClient
+	}
+	return http.DefaultClient
+}
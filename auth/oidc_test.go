@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	
+	"github.com/daarlabs/arcanum/cache"
+	"github.com/daarlabs/arcanum/cache/memory"
+	"github.com/daarlabs/arcanum/cookie"
+	"github.com/daarlabs/arcanum/quirk"
+)
+
+func TestOidc(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	challenges := make(map[string]string)
+	claims := make(map[string]map[string]any)
+	fetches := 0
+	var server *httptest.Server
+	server = httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case oidcDiscoveryPath:
+					_ = json.NewEncoder(w).Encode(
+						map[string]string{
+							"issuer":                 server.URL,
+							"authorization_endpoint": server.URL + "/authorize",
+							"token_endpoint":         server.URL + "/token",
+							"jwks_uri":               server.URL + "/jwks",
+						},
+					)
+				case "/jwks":
+					fetches++
+					_ = json.NewEncoder(w).Encode(
+						map[string]any{
+							"keys": []map[string]string{
+								{
+									"kty": "RSA",
+									"kid": "test",
+									"use": "sig",
+									"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+									"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+								},
+							},
+						},
+					)
+				case "/token":
+					code := r.PostFormValue("code")
+					verifier := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+					if challenges[code] != base64.RawURLEncoding.EncodeToString(verifier[:]) {
+						w.WriteHeader(http.StatusBadRequest)
+						_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+						return
+					}
+					_ = json.NewEncoder(w).Encode(map[string]string{"id_token": signTestJwt(t, key, claims[code])})
+				}
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	c := cache.New(context.Background(), memory.New(t.TempDir()), nil)
+	config := Config{
+		Providers: []Provider{
+			{Name: "test", Issuer: server.URL, ClientId: "client", ClientSecret: "secret", RedirectUrl: "http://app/callback"},
+		},
+	}
+	authorize := func(t *testing.T, code string, modify func(claims map[string]any)) (*http.Cookie, url.Values) {
+		req := httptest.NewRequest(http.MethodGet, "/login", nil)
+		res := httptest.NewRecorder()
+		m := &manager{req: req, res: res, cookie: cookie.New(req, res, "/"), cache: c, config: config}
+		redirect, err := m.Oidc("test").Redirect()
+		assert.NoError(t, err)
+		u, err := url.Parse(redirect)
+		assert.NoError(t, err)
+		query := u.Query()
+		assert.Equal(t, server.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+		assert.Equal(t, "S256", query.Get("code_challenge_method"))
+		challenges[code] = query.Get("code_challenge")
+		claims[code] = map[string]any{
+			"iss":            server.URL,
+			"sub":            "123",
+			"aud":            "client",
+			"exp":            time.Now().Add(time.Minute).Unix(),
+			"iat":            time.Now().Unix(),
+			"nonce":          query.Get("nonce"),
+			"email":          "Dominik@Linduska.dev",
+			"email_verified": true,
+		}
+		if modify != nil {
+			modify(claims[code])
+		}
+		return res.Result().Cookies()[0], query
+	}
+	callback := func(state *http.Cookie, query url.Values, code string) (Identity, error) {
+		req := httptest.NewRequest(http.MethodGet, "/callback?"+url.Values{"code": {code}, "state": {query.Get("state")}}.Encode(), nil)
+		req.AddCookie(state)
+		res := httptest.NewRecorder()
+		m := &manager{req: req, res: res, cookie: cookie.New(req, res, "/"), cache: c, config: config}
+		return m.Oidc("test").Identity()
+	}
+	t.Run(
+		"identity", func(t *testing.T) {
+			state, query := authorize(t, "valid", nil)
+			assert.Equal(t, http.SameSiteLaxMode, state.SameSite)
+			identity, err := callback(state, query, "valid")
+			assert.NoError(t, err)
+			assert.Equal(t, Identity{Provider: "test", Subject: "123", Email: "dominik@linduska.dev", EmailVerified: true}, identity)
+			_, err = callback(state, query, "valid")
+			assert.Error(t, err)
+		},
+	)
+	t.Run(
+		"invalid state", func(t *testing.T) {
+			state, query := authorize(t, "state", nil)
+			state.Value = "forged"
+			_, err := callback(state, query, "state")
+			assert.ErrorIs(t, err, ErrorInvalidState)
+		},
+	)
+	t.Run(
+		"invalid token", func(t *testing.T) {
+			for code, modify := range map[string]func(claims map[string]any){
+				"nonce":    func(claims map[string]any) { claims["nonce"] = "other" },
+				"audience": func(claims map[string]any) { claims["aud"] = []string{"other"} },
+				"expired":  func(claims map[string]any) { claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+			} {
+				state, query := authorize(t, code, modify)
+				_, err := callback(state, query, code)
+				assert.ErrorIs(t, err, ErrorInvalidToken, code)
+			}
+		},
+	)
+	t.Run(
+		"missing provider", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/login", nil)
+			res := httptest.NewRecorder()
+			m := &manager{req: req, res: res, cookie: cookie.New(req, res, "/"), cache: c, config: config}
+			_, err := m.Oidc("missing").Redirect()
+			assert.ErrorIs(t, err, ErrorMissingProvider)
+		},
+	)
+	t.Run(
+		"jwks refetch", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/login", nil)
+			res := httptest.NewRecorder()
+			m := &manager{req: req, res: res, cookie: cookie.New(req, res, "/"), cache: c, config: config}
+			om := m.Oidc("test").(*oidcManager)
+			discovery, err := om.discover()
+			assert.NoError(t, err)
+			_, err = om.key(discovery, "test")
+			assert.NoError(t, err)
+			count := fetches
+			for i := 0; i < 3; i++ {
+				_, err = om.key(discovery, "missing")
+				assert.ErrorIs(t, err, ErrorInvalidToken)
+			}
+			assert.Equal(t, count, fetches)
+		},
+	)
+	t.Run(
+		"callback", func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			db := quirk.Wrap(conn, quirk.Mysql)
+			store := &testUserStore{users: make(map[int]User)}
+			provider := config.Providers[0]
+			provider.AutoProvision = true
+			provisioned := Config{
+				Providers: []Provider{provider},
+				UserStore: store,
+				Password:  Password{MinLength: 8, MaxLength: 32, Symbol: true, Upper: true},
+			}
+			signIn := func(code string, modify func(claims map[string]any)) (In, error) {
+				state, query := authorize(t, code, modify)
+				req := httptest.NewRequest(http.MethodGet, "/callback?"+url.Values{"code": {code}, "state": {query.Get("state")}}.Encode(), nil)
+				req.AddCookie(state)
+				res := httptest.NewRecorder()
+				return New(db, req, res, cookie.New(req, res, "/"), c, provisioned).Oidc("test").Callback()
+			}
+			mock.ExpectQuery(`SELECT user_id FROM user_identities WHERE provider = \? AND subject = \?`).
+				WithArgs("test", "123").
+				WillReturnRows(sqlmock.NewRows([]string{IdentityUserId}))
+			mock.ExpectQuery(`INSERT INTO user_identities`).
+				WithArgs(1, "test", "123").
+				WillReturnRows(sqlmock.NewRows(nil))
+			in, err := signIn("provision", nil)
+			assert.NoError(t, err)
+			assert.True(t, in.Ok)
+			assert.Equal(t, "dominik@linduska.dev", store.users[1].Email)
+			assert.True(t, strings.HasPrefix(store.users[1].Password, "$argon2"))
+			mock.ExpectQuery(`SELECT user_id FROM user_identities`).
+				WithArgs("test", "123").
+				WillReturnRows(sqlmock.NewRows([]string{IdentityUserId}).AddRow(1))
+			in, err = signIn(
+				"linked", func(claims map[string]any) {
+					claims["email"] = "other@linduska.dev"
+					claims["email_verified"] = false
+				},
+			)
+			assert.NoError(t, err)
+			assert.True(t, in.Ok)
+			assert.Len(t, store.users, 1)
+			mock.ExpectQuery(`SELECT user_id FROM user_identities`).
+				WithArgs("test", "456").
+				WillReturnRows(sqlmock.NewRows([]string{IdentityUserId}))
+			_, err = signIn(
+				"unverified", func(claims map[string]any) {
+					claims["sub"] = "456"
+					claims["email_verified"] = false
+				},
+			)
+			assert.ErrorIs(t, err, ErrorUnverifiedEmail)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		},
+	)
+}
+
+func signTestJwt(t *testing.T, key *rsa.PrivateKey, claims map[string]any) string {
+	header, err := json.Marshal(jwtHeader{Alg: jwtAlgRs256, Kid: "test", Typ: "JWT"})
+	assert.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	assert.NoError(t, err)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	assert.NoError(t, err)
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	
+	"github.com/matthewhartstonge/argon2"
+	
+	"github.com/daarlabs/arcanum/quirk"
+)
+
+type Password struct {
+	MinLength   int    `json:"minLength" yaml:"minLength" toml:"minLength"`
+	MaxLength   int    `json:"maxLength" yaml:"maxLength" toml:"maxLength"`
+	Lower       bool   `json:"lower" yaml:"lower" toml:"lower"`
+	Upper       bool   `json:"upper" yaml:"upper" toml:"upper"`
+	Digit       bool   `json:"digit" yaml:"digit" toml:"digit"`
+	Symbol      bool   `json:"symbol" yaml:"symbol" toml:"symbol"`
+	History     int    `json:"history" yaml:"history" toml:"history"`
+	Breached    string `json:"breached" yaml:"breached" toml:"breached"`
+	TimeCost    uint32 `json:"timeCost" yaml:"timeCost" toml:"timeCost"`
+	MemoryCost  uint32 `json:"memoryCost" yaml:"memoryCost" toml:"memoryCost"`
+	Parallelism uint8  `json:"parallelism" yaml:"parallelism" toml:"parallelism"`
+}
+
+type passwordPolicy struct {
+	db     *quirk.DB
+	config Password
+}
+
+type passwordHistory struct {
+	Hash string `db:"hash"`
+}
+
+const (
+	PasswordHistoryUserId = "user_id"
+	PasswordHistoryHash   = "hash"
+)
+
+const (
+	passwordHistoryTable = "user_password_history"
+	breachedPrefixLength = 5
+	breachedFileSuffix   = ".txt"
+)
+
+func ValidatePassword(config Password, password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < config.MinLength {
+		return ErrorPasswordTooShort
+	}
+	if config.MaxLength > 0 && length > config.MaxLength {
+		return ErrorPasswordTooLong
+	}
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	if (config.Lower && !lower) || (config.Upper && !upper) || (config.Digit && !digit) || (config.Symbol && !symbol) {
+		return ErrorPasswordCharacters
+	}
+	breached, err := IsPasswordBreached(config.Breached, password)
+	if err != nil {
+		return err
+	}
+	if breached {
+		return ErrorPasswordBreached
+	}
+	return nil
+}
+
+func IsPasswordBreached(dir, password string) (bool, error) {
+	if len(dir) == 0 {
+		return false, nil
+	}
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	f, err := os.Open(filepath.Join(dir, hash[:breachedPrefixLength]+breachedFileSuffix))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		suffix, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if strings.EqualFold(suffix, hash[breachedPrefixLength:]) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+func createPasswordPolicy(db *quirk.DB, config Password) passwordPolicy {
+	return passwordPolicy{
+		db:     db,
+		config: config,
+	}
+}
+
+func (p passwordPolicy) create(password string) (string, error) {
+	if err := ValidatePassword(p.config, password); err != nil {
+		return "", err
+	}
+	return p.hash(password)
+}
+
+func (p passwordPolicy) hash(password string) (string, error) {
+	config := p.argon()
+	hash, err := config.HashEncoded([]byte(password))
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (p passwordPolicy) outdated(hash string) bool {
+	raw, err := argon2.Decode([]byte(hash))
+	if err != nil {
+		return false
+	}
+	config := p.argon()
+	return raw.Config.TimeCost != config.TimeCost ||
+		raw.Config.MemoryCost != config.MemoryCost ||
+		raw.Config.Parallelism != config.Parallelism ||
+		raw.Config.Mode != config.Mode ||
+		raw.Config.Version != config.Version
+}
+
+func (p passwordPolicy) reused(userId int, password string, current ...string) (bool, error) {
+	if p.config.History <= 0 {
+		return false, nil
+	}
+	hashes := current
+	if p.db != nil && userId > 0 {
+		history := make([]passwordHistory, 0)
+		err := quirk.New(p.db).
+			Q(fmt.Sprintf(`SELECT %s FROM %s`, PasswordHistoryHash, passwordHistoryTable)).
+			Q(`WHERE user_id = @user_id`, quirk.Map{PasswordHistoryUserId: userId}).
+			Q(fmt.Sprintf(`ORDER BY id DESC LIMIT %d`, p.config.History)).
+			Exec(&history)
+		if err != nil {
+			return false, err
+		}
+		for _, item := range history {
+			hashes = append(hashes, item.Hash)
+		}
+	}
+	for _, hash := range hashes {
+		if ok, err := argon2.VerifyEncoded([]byte(password), []byte(hash)); ok && err == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (p passwordPolicy) remember(userId int, hash string) error {
+	if p.config.History <= 0 || p.db == nil || userId == 0 {
+		return nil
+	}
+	err := quirk.New(p.db).Q(fmt.Sprintf(`INSERT INTO %s`, passwordHistoryTable)).
+		Q(fmt.Sprintf(`(%s, %s)`, PasswordHistoryUserId, PasswordHistoryHash)).
+		Q(`VALUES (@user_id, @hash)`, quirk.Map{PasswordHistoryUserId: userId, PasswordHistoryHash: hash}).
+		Exec()
+	if err != nil {
+		return err
+	}
+	keep := make([]int, 0)
+	err = quirk.New(p.db).
+		Q(fmt.Sprintf(`SELECT id FROM %s`, passwordHistoryTable)).
+		Q(`WHERE user_id = @user_id`, quirk.Map{PasswordHistoryUserId: userId}).
+		Q(fmt.Sprintf(`ORDER BY id DESC LIMIT %d`, p.config.History)).
+		Exec(&keep)
+	if err != nil || len(keep) == 0 {
+		return err
+	}
+	return quirk.New(p.db).
+		Q(fmt.Sprintf(`DELETE FROM %s`, passwordHistoryTable)).
+		Q(`WHERE user_id = @user_id AND id < @id`, quirk.Map{PasswordHistoryUserId: userId, quirk.Id: slices.Min(keep)}).
+		Exec()
+}
+
+func (p passwordPolicy) argon() argon2.Config {
+	config := argon2.DefaultConfig()
+	if p.config.TimeCost > 0 {
+		config.TimeCost = p.config.TimeCost
+	}
+	if p.config.MemoryCost > 0 {
+		config.MemoryCost = p.config.MemoryCost
+	}
+	if p.config.Parallelism > 0 {
+		config.Parallelism = p.config.Parallelism
+	}
+	return config
+}
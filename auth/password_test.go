@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/matthewhartstonge/argon2"
+	"github.com/stretchr/testify/assert"
+	
+	"github.com/daarlabs/arcanum/cache"
+	"github.com/daarlabs/arcanum/cache/memory"
+	"github.com/daarlabs/arcanum/cookie"
+	"github.com/daarlabs/arcanum/quirk"
+)
+
+func TestPassword(t *testing.T) {
+	t.Run(
+		"policy", func(t *testing.T) {
+			config := Password{MinLength: 8, MaxLength: 16, Lower: true, Upper: true, Digit: true, Symbol: true}
+			assert.ErrorIs(t, ValidatePassword(config, "Ab1!"), ErrorPasswordTooShort)
+			assert.ErrorIs(t, ValidatePassword(config, "Abcdefgh1!Abcdefgh1!"), ErrorPasswordTooLong)
+			assert.ErrorIs(t, ValidatePassword(config, "abcdefgh1!"), ErrorPasswordCharacters)
+			assert.ErrorIs(t, ValidatePassword(config, "Abcdefgh1"), ErrorPasswordCharacters)
+			assert.NoError(t, ValidatePassword(config, "Abcdefgh1!"))
+			assert.NoError(t, ValidatePassword(Password{}, ""))
+		},
+	)
+	t.Run(
+		"breached", func(t *testing.T) {
+			dir := t.TempDir()
+			sum := sha1.Sum([]byte("password"))
+			hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+			assert.NoError(
+				t,
+				os.WriteFile(
+					filepath.Join(dir, hash[:5]+".txt"),
+					[]byte("0018A45C4D1DEF81644B54AB7F969B88D65:1\n"+hash[5:]+":3730471\n"),
+					0644,
+				),
+			)
+			breached, err := IsPasswordBreached(dir, "password")
+			assert.NoError(t, err)
+			assert.True(t, breached)
+			breached, err = IsPasswordBreached(dir, "correct horse battery staple")
+			assert.NoError(t, err)
+			assert.False(t, breached)
+			assert.ErrorIs(t, ValidatePassword(Password{Breached: dir}, "password"), ErrorPasswordBreached)
+		},
+	)
+	t.Run(
+		"rehash reuse", func(t *testing.T) {
+			store := &testUserStore{users: make(map[int]User)}
+			userId := CreateCustomUserManager(store, nil, 0, "").MustCreate(
+				User{Active: true, Email: "dominik@linduska.dev", Password: "123456789"},
+			)
+			config := Config{
+				UserStore: store,
+				Throttle:  Throttle{Disabled: true},
+				Password:  Password{History: 3, TimeCost: 1, MemoryCost: 8 * 1024, Parallelism: 1},
+			}
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			res := httptest.NewRecorder()
+			c := cache.New(context.Background(), memory.New(t.TempDir()), nil)
+			m := New(nil, req, res, cookie.New(req, res, "/"), c, config)
+			assert.True(t, m.MustIn("dominik@linduska.dev", "123456789").Ok)
+			raw, err := argon2.Decode([]byte(store.users[userId].Password))
+			assert.NoError(t, err)
+			assert.Equal(t, uint32(1), raw.Config.TimeCost)
+			assert.Equal(t, uint32(8*1024), raw.Config.MemoryCost)
+			assert.True(t, m.MustIn("dominik@linduska.dev", "123456789").Ok)
+			assert.ErrorIs(t, m.CustomUser(userId, "").ForceUpdatePassword("123456789"), ErrorPasswordReused)
+			assert.NoError(t, m.CustomUser(userId, "").ForceUpdatePassword("987654321"))
+		},
+	)
+	t.Run(
+		"import", func(t *testing.T) {
+			policy := createPasswordPolicy(nil, Password{TimeCost: 1, MemoryCost: 1024})
+			hash, err := policy.hash("123456789")
+			assert.NoError(t, err)
+			created, err := policy.create(hash)
+			assert.NoError(t, err)
+			assert.NotEqual(t, hash, created)
+			store := &testUserStore{users: make(map[int]User)}
+			userId := CreateCustomUserManager(store, nil, 0, "").MustImport(
+				User{Active: true, Email: "dominik@linduska.dev", Password: hash},
+			)
+			assert.Equal(t, hash, store.users[userId].Password)
+			_, err = CreateCustomUserManager(store, nil, 0, "").Import(User{Email: "other@linduska.dev", Password: "123456789"})
+			assert.ErrorIs(t, err, ErrorInvalidPasswordHash)
+			um := CreateCustomUserManager(store, nil, userId, "")
+			user := um.MustGet()
+			user.FirstName = "Dominik"
+			um.MustUpdate(user)
+			assert.Equal(t, hash, store.users[userId].Password)
+			assert.Equal(t, "Dominik", store.users[userId].FirstName)
+			createdId := CreateCustomUserManager(store, nil, 0, "").MustCreate(
+				User{Active: true, Email: "legacy@linduska.dev", Password: hash},
+			)
+			assert.Equal(t, hash, store.users[createdId].Password)
+		},
+	)
+	t.Run(
+		"update password", func(t *testing.T) {
+			store := &testUserStore{users: make(map[int]User)}
+			userId := CreateCustomUserManager(store, nil, 0, "").MustCreate(
+				User{Active: true, Email: "dominik@linduska.dev", Password: "123456789"},
+			)
+			events := make([]Event, 0)
+			config := Config{
+				UserStore: store,
+				Throttle:  Throttle{Disabled: true},
+				Password:  Password{History: 3},
+				OnEvent: func(event Event) {
+					events = append(events, event)
+				},
+				RevokeSessionsOnPasswordUpdate: true,
+			}
+			c := cache.New(context.Background(), memory.New(t.TempDir()), nil)
+			createTestManager := func() Manager {
+				req := httptest.NewRequest(http.MethodPost, "/", nil)
+				res := httptest.NewRecorder()
+				return New(nil, req, res, cookie.New(req, res, "/"), c, config)
+			}
+			assert.True(t, createTestManager().MustIn("dominik@linduska.dev", "123456789").Ok)
+			m := createTestManager()
+			assert.True(t, m.MustIn("dominik@linduska.dev", "123456789").Ok)
+			assert.Len(t, m.Session().MustSessions(userId), 2)
+			um := m.CustomUser(userId, "")
+			user := um.MustGet()
+			user.Password = "123456789"
+			assert.ErrorIs(t, um.Update(user), ErrorPasswordReused)
+			user.FirstName = "Dominik"
+			user.Password = "987654321"
+			um.MustUpdate(user)
+			assert.Equal(t, "Dominik", store.users[userId].FirstName)
+			ok, err := argon2.VerifyEncoded([]byte("987654321"), []byte(store.users[userId].Password))
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, EventPasswordChange, events[len(events)-1].Name)
+			assert.Empty(t, m.Session().MustSessions(userId))
+		},
+	)
+	t.Run(
+		"history mysql", func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			policy := createPasswordPolicy(quirk.Wrap(conn, quirk.Mysql), Password{History: 2})
+			mock.ExpectQuery(`INSERT INTO user_password_history`).WithArgs(1, "hash").WillReturnRows(sqlmock.NewRows(nil))
+			mock.ExpectQuery(`SELECT id FROM user_password_history WHERE user_id = \? ORDER BY id DESC LIMIT 2`).
+				WithArgs(1).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9).AddRow(8))
+			mock.ExpectQuery(`DELETE FROM user_password_history WHERE user_id = \? AND id < \?`).
+				WithArgs(1, 8).
+				WillReturnRows(sqlmock.NewRows(nil))
+			assert.NoError(t, policy.remember(1, "hash"))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		},
+	)
+}
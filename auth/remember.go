@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"fmt"
+	"strings"
+	"time"
+	
+	"github.com/dchest/uniuri"
+	
+	"github.com/daarlabs/arcanum/cookie"
+	"github.com/daarlabs/arcanum/quirk"
+)
+
+type RememberMe struct {
+	Duration time.Duration `json:"duration" yaml:"duration" toml:"duration"`
+}
+
+type rememberToken struct {
+	Id        int       `db:"id"`
+	UserId    int       `db:"user_id"`
+	Hash      string    `db:"hash"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+const (
+	RememberUserId    = "user_id"
+	RememberSelector  = "selector"
+	RememberHash      = "hash"
+	RememberExpiresAt = "expires_at"
+)
+
+const (
+	RememberCookieKey       = "X-Remember"
+	DefaultRememberDuration = 30 * 24 * time.Hour
+)
+
+const (
+	rememberTable           = "user_remember_tokens"
+	rememberSelectorLength  = 16
+	rememberValidatorLength = 40
+	rememberGraceDuration   = time.Minute
+	rememberEventDetail     = "remember-me"
+)
+
+func (m *manager) Remember() error {
+	session, err := m.Session().Get()
+	if err != nil {
+		return err
+	}
+	if session.Id == 0 || len(session.Token) == 0 {
+		return ErrorMissingSessionCookie
+	}
+	selector := uniuri.NewLen(rememberSelectorLength)
+	validator := uniuri.NewLen(rememberValidatorLength)
+	duration := m.rememberDuration()
+	err = quirk.New(m.db).Q(fmt.Sprintf(`INSERT INTO %s`, rememberTable)).
+		Q(fmt.Sprintf(`(%s, %s, %s, %s)`, RememberUserId, RememberSelector, RememberHash, RememberExpiresAt)).
+		Q(
+			`VALUES (@user_id, @selector, @hash, @expires_at)`,
+			quirk.Map{
+				RememberUserId:    session.Id,
+				RememberSelector:  selector,
+				RememberHash:      hashToken(validator),
+				RememberExpiresAt: time.Now().Add(duration),
+			},
+		).
+		Exec()
+	if err != nil {
+		return err
+	}
+	m.cookie.Set(RememberCookieKey, selector+":"+validator, duration, cookie.HttpOnly())
+	return nil
+}
+
+func (m *manager) MustRemember() {
+	if err := m.Remember(); err != nil {
+		panic(err)
+	}
+}
+
+func (m *manager) Forget() error {
+	selector, _, ok := strings.Cut(m.cookie.Get(RememberCookieKey), ":")
+	if !ok {
+		return nil
+	}
+	m.cookie.Set(RememberCookieKey, "", time.Millisecond)
+	return quirk.New(m.db).
+		Q(fmt.Sprintf(`DELETE FROM %s`, rememberTable)).
+		Q(`WHERE selector = @selector`, quirk.Map{RememberSelector: selector}).
+		Exec()
+}
+
+func (m *manager) MustForget() {
+	if err := m.Forget(); err != nil {
+		panic(err)
+	}
+}
+
+func (m *manager) restore() (string, error) {
+	value := m.cookie.Get(RememberCookieKey)
+	selector, validator, ok := strings.Cut(value, ":")
+	if !ok || len(selector) == 0 || len(validator) == 0 {
+		return "", nil
+	}
+	var restored string
+	if err := m.cache.Get(createRememberCacheKey(hashToken(value)), &restored); err != nil {
+		return "", err
+	}
+	if len(restored) > 0 {
+		return restored, nil
+	}
+	var r rememberToken
+	err := quirk.New(m.db).
+		Q(fmt.Sprintf(`SELECT id, %s, %s, %s FROM %s`, RememberUserId, RememberHash, RememberExpiresAt, rememberTable)).
+		Q(`WHERE selector = @selector`, quirk.Map{RememberSelector: selector}).
+		Q(`AND expires_at > CURRENT_TIMESTAMP`).
+		Exec(&r)
+	if err != nil {
+		return "", err
+	}
+	if r.Id == 0 {
+		m.cookie.Set(RememberCookieKey, "", time.Millisecond)
+		return "", nil
+	}
+	if !hmac.Equal([]byte(r.Hash), []byte(hashToken(validator))) {
+		m.cookie.Set(RememberCookieKey, "", time.Millisecond)
+		return "", m.forgetAll(r.UserId, false)
+	}
+	user, err := m.userStore().Get(r.UserId, "")
+	if err != nil {
+		return "", err
+	}
+	if user.Id == 0 || !user.Active {
+		return "", m.Forget()
+	}
+	validator = uniuri.NewLen(rememberValidatorLength)
+	err = quirk.New(m.db).
+		Q(fmt.Sprintf(`UPDATE %s SET %s = @hash`, rememberTable, RememberHash), quirk.Map{RememberHash: hashToken(validator)}).
+		Q(`WHERE id = @id`, quirk.Map{quirk.Id: r.Id}).
+		Exec()
+	if err != nil {
+		return "", err
+	}
+	m.cookie.Set(RememberCookieKey, selector+":"+validator, time.Until(r.ExpiresAt), cookie.HttpOnly())
+	token, err := m.Session().New(user)
+	if err != nil {
+		return "", err
+	}
+	if err := m.cache.Set(createRememberCacheKey(hashToken(value)), token, rememberGraceDuration); err != nil {
+		return "", err
+	}
+	m.emit(Event{Name: EventLoginSuccess, UserId: user.Id, Email: user.Email, Detail: rememberEventDetail})
+	return token, nil
+}
+
+func (m *manager) forgetAll(userId int, exceptCurrent bool) error {
+	q := quirk.New(m.db).
+		Q(fmt.Sprintf(`DELETE FROM %s`, rememberTable)).
+		Q(`WHERE user_id = @user_id`, quirk.Map{RememberUserId: userId})
+	if selector, _, ok := strings.Cut(m.cookie.Get(RememberCookieKey), ":"); exceptCurrent && ok {
+		q.Q(`AND selector <> @selector`, quirk.Map{RememberSelector: selector})
+	}
+	return q.Exec()
+}
+
+func (m *manager) forget(userId int) error {
+	if err := m.forgetDevices(userId); err != nil {
+		return err
+	}
+	if m.db == nil {
+		return nil
+	}
+	return m.forgetAll(userId, true)
+}
+
+func (m *manager) rememberDuration() time.Duration {
+	if m.config.RememberMe.Duration == 0 {
+		return DefaultRememberDuration
+	}
+	return m.config.RememberMe.Duration
+}
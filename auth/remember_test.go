@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	
+	"github.com/daarlabs/arcanum/cache"
+	"github.com/daarlabs/arcanum/cache/memory"
+	"github.com/daarlabs/arcanum/cookie"
+	"github.com/daarlabs/arcanum/quirk"
+)
+
+func TestRemember(t *testing.T) {
+	db := createTestDatabaseConnection(t)
+	assert.NoError(t, DropTable(db))
+	assert.NoError(t, CreateTable(db))
+	t.Cleanup(
+		func() {
+			assert.NoError(t, DropTable(db))
+		},
+	)
+	userId := CreateUserManager(db, nil, 0, "").MustCreate(
+		User{Active: true, Email: "dominik@linduska.dev", Password: "123456789"},
+	)
+	c := cache.New(context.Background(), memory.New(t.TempDir()), nil)
+	createTestManager := func(cookies ...*http.Cookie) (Manager, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for _, item := range cookies {
+			req.AddCookie(item)
+		}
+		res := httptest.NewRecorder()
+		return New(db, req, res, cookie.New(req, res, "/"), c, Config{}), res
+	}
+	getCookie := func(res *httptest.ResponseRecorder, name string) *http.Cookie {
+		var r *http.Cookie
+		for _, item := range res.Result().Cookies() {
+			if item.Name == name {
+				r = item
+			}
+		}
+		return r
+	}
+	m, _ := createTestManager()
+	in := m.MustIn("dominik@linduska.dev", "123456789")
+	m, res := createTestManager(&http.Cookie{Name: SessionCookieKey, Value: in.Token})
+	m.MustRemember()
+	remember := getCookie(res, RememberCookieKey)
+	t.Run(
+		"restore", func(t *testing.T) {
+			m, res := createTestManager(remember)
+			session := m.Session().MustGet()
+			assert.Equal(t, userId, session.Id)
+			assert.NotEqual(t, in.Token, session.Token)
+			rotated := getCookie(res, RememberCookieKey)
+			assert.NotEqual(t, remember.Value, rotated.Value)
+			assert.Equal(t, session.Token, m.Session().MustGet().Token)
+			m, _ = createTestManager(rotated)
+			assert.Equal(t, userId, m.Session().MustGet().Id)
+		},
+	)
+	t.Run(
+		"forget", func(t *testing.T) {
+			m, _ := createTestManager()
+			in := m.MustIn("dominik@linduska.dev", "123456789")
+			m, res := createTestManager(&http.Cookie{Name: SessionCookieKey, Value: in.Token})
+			m.MustRemember()
+			remember := getCookie(res, RememberCookieKey)
+			m, _ = createTestManager(&http.Cookie{Name: SessionCookieKey, Value: in.Token}, remember)
+			m.MustOut()
+			m, _ = createTestManager(remember)
+			assert.Equal(t, 0, m.Session().MustGet().Id)
+		},
+	)
+}
+
+func TestRememberRevoke(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	db := quirk.Wrap(conn, quirk.Mysql)
+	c := cache.New(context.Background(), memory.New(t.TempDir()), nil)
+	store := &testUserStore{users: map[int]User{1: {Id: 1, Active: true, Email: "dominik@linduska.dev"}}}
+	config := Config{UserStore: store, Password: Password{TimeCost: 1, MemoryCost: 1024}}
+	createTestManager := func() Manager {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: RememberCookieKey, Value: "selector:validator"})
+		res := httptest.NewRecorder()
+		return New(db, req, res, cookie.New(req, res, "/"), c, config)
+	}
+	t.Run(
+		"revoke all", func(t *testing.T) {
+			mock.ExpectQuery(`DELETE FROM user_remember_tokens WHERE user_id = \? AND selector <> \?`).
+				WithArgs(1, "selector").
+				WillReturnRows(sqlmock.NewRows(nil))
+			assert.NoError(t, createTestManager().Session().RevokeAll(1, true))
+			mock.ExpectQuery(`DELETE FROM user_remember_tokens WHERE user_id = \?;`).
+				WithArgs(1).
+				WillReturnRows(sqlmock.NewRows(nil))
+			assert.NoError(t, createTestManager().Session().RevokeAll(1, false))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		},
+	)
+	t.Run(
+		"password change", func(t *testing.T) {
+			mock.ExpectQuery(`DELETE FROM user_remember_tokens WHERE user_id = \? AND selector <> \?`).
+				WithArgs(1, "selector").
+				WillReturnRows(sqlmock.NewRows(nil))
+			assert.NoError(t, createTestManager().CustomUser(1, "").ForceUpdatePassword("123456789"))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		},
+	)
+}
@@ -1,6 +1,9 @@
 package auth
 
-import "slices"
+import (
+	"slices"
+	"strings"
+)
 
 type Role struct {
 	Name       string   `json:"name"`
@@ -8,6 +11,10 @@ type Role struct {
 	Securables []string `json:"securables"`
 }
 
+const (
+	PermissionAll = "*"
+)
+
 func (r Role) Compare(role Role) bool {
 	if r.Name != role.Name {
 		return false
@@ -25,3 +32,26 @@ func (r Role) Compare(role Role) bool {
 	}
 	return true
 }
+
+func MatchPermission(securable, permission string) bool {
+	if securable == PermissionAll || securable == permission {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(securable, ".*")
+	return ok && strings.HasPrefix(permission, prefix+".")
+}
+
+func createPermissions(configRoles []Role, roles ...string) []string {
+	result := make([]string, 0)
+	for _, r := range configRoles {
+		if !slices.Contains(roles, r.Name) {
+			continue
+		}
+		for _, s := range r.Securables {
+			if !slices.Contains(result, s) {
+				result = append(result, s)
+			}
+		}
+	}
+	return result
+}
@@ -35,4 +35,35 @@ func TestRole(t *testing.T) {
 			assert.False(t, r1.Compare(r2))
 		},
 	)
+	t.Run(
+		"can", func(t *testing.T) {
+			r := Role{Name: "accountant", Securables: []string{"invoice.*", "report.view"}}
+			session := Session{Id: 1, Permissions: r.Securables}
+			assert.True(t, session.Can("invoice.edit"))
+			assert.True(t, session.Can("invoice.item.delete"))
+			assert.True(t, session.Can("report.view"))
+			assert.False(t, session.Can("invoice"))
+			assert.False(t, session.Can("report.edit"))
+			assert.False(t, session.Can("invoices.edit"))
+			assert.True(t, Session{Id: 1, Permissions: []string{PermissionAll}}.Can("users.delete"))
+		},
+	)
+	t.Run(
+		"session can", func(t *testing.T) {
+			roles := []Role{
+				{Name: "accountant", Securables: []string{"invoice.*"}},
+				{Name: "viewer", Securables: []string{"report.view", "invoice.view"}},
+			}
+			permissions := createPermissions(roles, "accountant", "viewer")
+			assert.Equal(t, []string{"invoice.*", "report.view", "invoice.view"}, permissions)
+			session := Session{Id: 1, Permissions: permissions}
+			assert.True(t, session.Can("invoice.edit"))
+			assert.False(t, session.Can("report.edit"))
+			session.Scopes = []string{"invoice.view"}
+			assert.True(t, session.Can("invoice.view"))
+			assert.False(t, session.Can("invoice.edit"))
+			assert.False(t, Session{Permissions: permissions}.Can("invoice.edit"))
+			assert.True(t, Session{Id: 1, Super: true}.Can("invoice.edit"))
+		},
+	)
 }
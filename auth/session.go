@@ -3,6 +3,7 @@ package auth
 import (
 	"net/http"
 	"slices"
+	"strings"
 	"time"
 	
 	"github.com/dchest/uniuri"
@@ -17,30 +18,55 @@ type SessionManager interface {
 	Get(token ...string) (Session, error)
 	New(user User) (string, error)
 	Renew() error
+	Slide() error
 	Destroy() error
+	Sessions(userId int) ([]Session, error)
+	Revoke(userId int, device string) error
+	RevokeAll(userId int, exceptCurrent bool) error
 	
 	MustExists() bool
 	MustGet(token ...string) Session
 	MustNew(user User) string
 	MustRenew()
+	MustSlide()
 	MustDestroy()
+	MustSessions(userId int) []Session
+	MustRevoke(userId int, device string)
+	MustRevokeAll(userId int, exceptCurrent bool)
 }
 
 type Session struct {
-	Id        int      `json:"id"`
-	Email     string   `json:"email"`
-	Roles     []string `json:"role"`
-	Super     bool     `json:"super"`
-	Ip        string   `json:"ip"`
-	UserAgent string   `json:"userAgent"`
+	Id          int       `json:"id"`
+	Token       string    `json:"token"`
+	Device      string    `json:"device,omitempty"`
+	Email       string    `json:"email"`
+	FirstName   string    `json:"firstName,omitempty"`
+	LastName    string    `json:"lastName,omitempty"`
+	Locale      string    `json:"locale,omitempty"`
+	TenantId    int       `json:"tenantId,omitempty"`
+	Roles       []string  `json:"role"`
+	Super       bool      `json:"super"`
+	Ip          string    `json:"ip"`
+	UserAgent   string    `json:"userAgent"`
+	CreatedAt   time.Time `json:"createdAt"`
+	LastSeenAt  time.Time `json:"lastSeenAt"`
+	Scopes      []string  `json:"scopes,omitempty"`
+	Permissions []string  `json:"permissions,omitempty"`
+	
+	ImpersonatorId  int `json:"impersonatorId,omitempty"`
+	ImpersonationId int `json:"impersonationId,omitempty"`
 }
 
 type sessionManager struct {
-	req    *http.Request
-	res    http.ResponseWriter
-	cookie cookie.Cookie
-	cache  cache.Client
-	config Config
+	req     *http.Request
+	res     http.ResponseWriter
+	cookie  cookie.Cookie
+	cache   cache.Client
+	tokens  TokenManager
+	emit    func(event Event)
+	restore func() (string, error)
+	forget  func(userId int, exceptCurrent bool) error
+	config  Config
 }
 
 const (
@@ -93,7 +119,27 @@ func (s sessionManager) Get(token ...string) (Session, error) {
 	if len(token) > 0 {
 		t = token[0]
 	}
+	if len(t) == 0 && s.tokens != nil && s.config.Bearer {
+		if bearer := GetBearerToken(s.req); len(bearer) > 0 {
+			return s.tokens.Verify(bearer)
+		}
+	}
 	err := s.cache.Get(createSessionCacheKey(t), &r)
+	if err == nil && r.Id == 0 {
+		r, err = s.migrate(t)
+	}
+	if err == nil && r.Id == 0 && len(token) == 0 && s.restore != nil {
+		if t, err = s.restore(); err != nil || len(t) == 0 {
+			return r, err
+		}
+		err = s.cache.Get(createSessionCacheKey(t), &r)
+	}
+	if len(r.Token) == 0 && r.Id > 0 {
+		r.Token = t
+	}
+	if r.Id > 0 {
+		r = s.resolve(r)
+	}
 	return r, err
 }
 
@@ -107,11 +153,9 @@ func (s sessionManager) MustGet(token ...string) Session {
 
 func (s sessionManager) New(user User) (string, error) {
 	token := uniuri.New()
-	if s.config.Duration.Hours() == 0 {
-		s.config.Duration = DefaultDuration
-	}
-	s.cookie.Set(SessionCookieKey, token, s.config.Duration)
-	return token, s.cache.Set(createSessionCacheKey(token), s.createSession(user), s.config.Duration)
+	session := s.createSession(token, user)
+	s.cookie.Set(SessionCookieKey, token, s.ttl(session))
+	return token, s.store(session)
 }
 
 func (s sessionManager) MustNew(user User) string {
@@ -123,22 +167,11 @@ func (s sessionManager) MustNew(user User) string {
 }
 
 func (s sessionManager) Renew() error {
-	token := s.Token()
-	if len(token) == 0 {
-		return ErrorMissingSessionCookie
-	}
-	session, err := s.Get()
+	session, err := s.current()
 	if err != nil {
 		return err
 	}
-	if session.Id == 0 || session.Ip != s.getIp() || session.UserAgent != s.getUserAgent() {
-		return ErrorCredentialsMismatch
-	}
-	if s.config.Duration.Hours() == 0 {
-		s.config.Duration = DefaultDuration
-	}
-	s.cookie.Set(SessionCookieKey, token, s.config.Duration)
-	return s.cache.Set(createSessionCacheKey(token), session, s.config.Duration)
+	return s.renew(session)
 }
 
 func (s sessionManager) MustRenew() {
@@ -148,10 +181,27 @@ func (s sessionManager) MustRenew() {
 	}
 }
 
+func (s sessionManager) Slide() error {
+	session, err := s.current()
+	if err != nil {
+		return err
+	}
+	if time.Since(session.LastSeenAt) < s.duration()/2 {
+		return nil
+	}
+	return s.renew(session)
+}
+
+func (s sessionManager) MustSlide() {
+	if err := s.Slide(); err != nil {
+		panic(err)
+	}
+}
+
 func (s sessionManager) Destroy() error {
 	token := s.Token()
 	s.cookie.Set(SessionCookieKey, "", time.Millisecond)
-	return s.cache.Set(createSessionCacheKey(token), "", time.Millisecond)
+	return s.revoke(token)
 }
 
 func (s sessionManager) MustDestroy() {
@@ -160,27 +210,272 @@ func (s sessionManager) MustDestroy() {
 	}
 }
 
-func (s sessionManager) createSession(user User) Session {
+func (s sessionManager) Sessions(userId int) ([]Session, error) {
+	tokens, err := s.getUserTokens(userId)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(tokens))
+	for i, token := range tokens {
+		keys[i] = createSessionCacheKey(token)
+	}
+	sessions := make(map[string]Session)
+	if err := s.cache.GetMany(keys, &sessions); err != nil {
+		return nil, err
+	}
+	result := make([]Session, 0, len(sessions))
+	stale := make([]string, 0)
+	for _, token := range tokens {
+		session, ok := sessions[createSessionCacheKey(token)]
+		if !ok || session.Id != userId {
+			stale = append(stale, createSessionUserCacheKey(userId, token))
+			continue
+		}
+		session.Token, session.Device = "", hashToken(token)
+		result = append(result, session)
+	}
+	slices.SortFunc(
+		result, func(a, b Session) int {
+			return b.LastSeenAt.Compare(a.LastSeenAt)
+		},
+	)
+	return result, s.cache.DestroyMany(stale...)
+}
+
+func (s sessionManager) MustSessions(userId int) []Session {
+	sessions, err := s.Sessions(userId)
+	if err != nil {
+		panic(err)
+	}
+	return sessions
+}
+
+func (s sessionManager) Revoke(userId int, device string) error {
+	tokens, err := s.getUserTokens(userId)
+	if err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		if hashToken(token) == device {
+			return s.revoke(token)
+		}
+	}
+	return nil
+}
+
+func (s sessionManager) MustRevoke(userId int, device string) {
+	if err := s.Revoke(userId, device); err != nil {
+		panic(err)
+	}
+}
+
+func (s sessionManager) revoke(token string) error {
+	if len(token) == 0 {
+		return nil
+	}
+	var session Session
+	if err := s.cache.Get(createSessionCacheKey(token), &session); err != nil {
+		return err
+	}
+	keys := []string{createSessionCacheKey(token)}
+	if session.Id > 0 {
+		keys = append(keys, createSessionUserCacheKey(session.Id, token))
+	}
+	return s.cache.DestroyMany(keys...)
+}
+
+func (s sessionManager) RevokeAll(userId int, exceptCurrent bool) error {
+	tokens, err := s.getUserTokens(userId)
+	if err != nil {
+		return err
+	}
+	current := s.Token()
+	keys := make([]string, 0, len(tokens)*2)
+	for _, token := range tokens {
+		if exceptCurrent && token == current {
+			continue
+		}
+		keys = append(keys, createSessionCacheKey(token), createSessionUserCacheKey(userId, token))
+	}
+	if err := s.cache.DestroyMany(keys...); err != nil {
+		return err
+	}
+	return s.forgetAll(userId, exceptCurrent)
+}
+
+func (s sessionManager) MustRevokeAll(userId int, exceptCurrent bool) {
+	if err := s.RevokeAll(userId, exceptCurrent); err != nil {
+		panic(err)
+	}
+}
+
+func (s sessionManager) impersonate(user User, impersonator Session, impersonationId int) error {
+	token := uniuri.New()
+	session := s.createSession(token, user)
+	session.ImpersonatorId = impersonator.Id
+	session.ImpersonationId = impersonationId
+	if err := s.cache.Set(createImpersonationCacheKey(impersonationId), impersonator.Token, s.ttl(impersonator)); err != nil {
+		return err
+	}
+	s.cookie.Set(SessionCookieKey, token, s.ttl(session))
+	return s.store(session)
+}
+
+func (s sessionManager) forgetAll(userId int, exceptCurrent bool) error {
+	if s.forget == nil {
+		return nil
+	}
+	return s.forget(userId, exceptCurrent)
+}
+
+func (s sessionManager) duration() time.Duration {
+	if s.config.Duration.Hours() == 0 {
+		return DefaultDuration
+	}
+	return s.config.Duration
+}
+
+func (s sessionManager) current() (Session, error) {
+	if len(s.Token()) == 0 && s.restore == nil {
+		return Session{}, ErrorMissingSessionCookie
+	}
+	session, err := s.Get()
+	if err != nil {
+		return session, err
+	}
+	if len(session.Token) == 0 {
+		return session, ErrorMissingSessionCookie
+	}
+	if session.Id == 0 || session.Ip != s.getIp() || session.UserAgent != s.getUserAgent() {
+		return session, ErrorCredentialsMismatch
+	}
+	if s.ttl(session) <= 0 {
+		s.cookie.Set(SessionCookieKey, "", time.Millisecond)
+		if err := s.revoke(session.Token); err != nil {
+			return session, err
+		}
+		return session, ErrorSessionExpired
+	}
+	return session, nil
+}
+
+func (s sessionManager) renew(session Session) error {
+	session.LastSeenAt = time.Now()
+	s.cookie.Set(SessionCookieKey, session.Token, s.ttl(session))
+	if err := s.store(session); err != nil {
+		return err
+	}
+	s.renewed(session)
+	return nil
+}
+
+func (s sessionManager) renewed(session Session) {
+	if s.emit == nil {
+		return
+	}
+	s.emit(Event{Name: EventSessionRenew, UserId: session.Id, Email: session.Email})
+}
+
+func (s sessionManager) ttl(session Session) time.Duration {
+	if s.config.MaxLifetime <= 0 {
+		return s.duration()
+	}
+	return min(s.duration(), time.Until(session.CreatedAt.Add(s.config.MaxLifetime)))
+}
+
+func (s sessionManager) store(session Session) error {
+	ttl := s.ttl(session)
+	if err := s.cache.Set(createSessionCacheKey(session.Token), session, ttl); err != nil {
+		return err
+	}
+	return s.cache.Set(createSessionUserCacheKey(session.Id, session.Token), session.Token, ttl)
+}
+
+func (s sessionManager) migrate(token string) (Session, error) {
+	var r Session
+	if s.config.LegacyCache == nil || len(token) == 0 {
+		return r, nil
+	}
+	key := createSessionCacheKey(token)
+	if err := s.config.LegacyCache.Get(key, &r); err != nil || r.Id == 0 {
+		return r, err
+	}
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	if r.LastSeenAt.IsZero() {
+		r.LastSeenAt = r.CreatedAt
+	}
+	r.Token = token
+	if err := s.store(r); err != nil {
+		return r, err
+	}
+	return r, s.config.LegacyCache.Destroy(key)
+}
+
+func (s sessionManager) getUserTokens(userId int) ([]string, error) {
+	prefix := createSessionUserCacheKey(userId, "")
+	keys, err := s.cache.Keys(prefix + "*")
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]string, len(keys))
+	for i, key := range keys {
+		tokens[i] = strings.TrimPrefix(key, prefix)
+	}
+	return tokens, nil
+}
+
+func (s sessionManager) createSession(token string, user User) Session {
+	t := time.Now()
 	return Session{
-		Id:        user.Id,
-		Email:     user.Email,
-		Ip:        s.getIp(),
-		UserAgent: s.getUserAgent(),
-		Roles:     user.Roles,
-		Super:     s.containsSuperRole(user.Roles...),
+		Id:          user.Id,
+		Token:       token,
+		Email:       user.Email,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		Locale:      user.Locale,
+		TenantId:    user.TenantId,
+		Ip:          s.getIp(),
+		UserAgent:   s.getUserAgent(),
+		Roles:       user.Roles,
+		Super:       containsSuperRole(s.config.Roles, user.Roles...),
+		Permissions: createPermissions(s.config.Roles, user.Roles...),
+		CreatedAt:   t,
+		LastSeenAt:  t,
 	}
 }
 
+func (s sessionManager) resolve(session Session) Session {
+	session.Super = containsSuperRole(s.config.Roles, session.Roles...)
+	session.Permissions = createPermissions(s.config.Roles, session.Roles...)
+	return session
+}
+
 func (s sessionManager) getIp() string {
-	return s.req.Header.Get("X-Forwarded-For")
+	return getRequestIp(s.req, s.config.TrustedProxies)
 }
 
 func (s sessionManager) getUserAgent() string {
 	return s.req.Header.Get("User-Agent")
 }
 
-func (s sessionManager) containsSuperRole(roles ...string) bool {
-	for _, r := range s.config.Roles {
+func (s Session) Can(permission string) bool {
+	if s.Id == 0 || !s.HasScope(permission) {
+		return false
+	}
+	if s.Super {
+		return true
+	}
+	return slices.ContainsFunc(
+		s.Permissions, func(securable string) bool {
+			return MatchPermission(securable, permission)
+		},
+	)
+}
+
+func containsSuperRole(configRoles []Role, roles ...string) bool {
+	for _, r := range configRoles {
 		if slices.Contains(roles, r.Name) && r.Super {
 			return true
 		}
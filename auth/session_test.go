@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	
+	"github.com/stretchr/testify/assert"
+	
+	"github.com/daarlabs/arcanum/cache"
+	"github.com/daarlabs/arcanum/cache/memory"
+	"github.com/daarlabs/arcanum/cookie"
+)
+
+func TestSession(t *testing.T) {
+	c := cache.New(context.Background(), memory.New(t.TempDir()), nil).Namespace("auth")
+	user := User{Id: 1, Email: "dominik@linduska.dev", Roles: []string{"owner"}}
+	createTestSessionManager := func(token string, userAgent string) (SessionManager, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("User-Agent", userAgent)
+		if len(token) > 0 {
+			req.AddCookie(&http.Cookie{Name: SessionCookieKey, Value: token})
+		}
+		res := httptest.NewRecorder()
+		return createSessionManager(req, res, cookie.New(req, res, "/"), c, Config{}), res
+	}
+	sm, _ := createTestSessionManager("", "desktop")
+	desktop := sm.MustNew(user)
+	sm, _ = createTestSessionManager("", "mobile")
+	mobile := sm.MustNew(user)
+	sm, _ = createTestSessionManager("", "tablet")
+	tablet := sm.MustNew(user)
+	sm, _ = createTestSessionManager("", "other")
+	other := sm.MustNew(User{Id: 2})
+	t.Run(
+		"sessions", func(t *testing.T) {
+			sm, _ := createTestSessionManager(mobile, "mobile")
+			sm.MustRenew()
+			sessions := sm.MustSessions(user.Id)
+			assert.Len(t, sessions, 3)
+			assert.Empty(t, sessions[0].Token)
+			assert.Equal(t, hashToken(mobile), sessions[0].Device)
+			assert.Equal(t, "mobile", sessions[0].UserAgent)
+			assert.False(t, sessions[0].CreatedAt.IsZero())
+			assert.True(t, sessions[0].LastSeenAt.After(sessions[0].CreatedAt))
+		},
+	)
+	t.Run(
+		"revoke", func(t *testing.T) {
+			sm, _ := createTestSessionManager(desktop, "desktop")
+			sm.MustRevoke(user.Id, hashToken(tablet))
+			sm.MustRevoke(2, hashToken(desktop))
+			assert.Len(t, sm.MustSessions(user.Id), 2)
+			assert.Equal(t, 0, sm.MustGet(tablet).Id)
+		},
+	)
+	t.Run(
+		"revoke all", func(t *testing.T) {
+			sm, _ := createTestSessionManager(desktop, "desktop")
+			sm.MustRevokeAll(user.Id, true)
+			sessions := sm.MustSessions(user.Id)
+			assert.Len(t, sessions, 1)
+			assert.Equal(t, hashToken(desktop), sessions[0].Device)
+			assert.True(t, sm.MustExists())
+			sm.MustRevokeAll(user.Id, false)
+			assert.False(t, sm.MustExists())
+			assert.Len(t, sm.MustSessions(2), 1)
+			assert.Equal(t, hashToken(other), sm.MustSessions(2)[0].Device)
+		},
+	)
+	t.Run(
+		"slide", func(t *testing.T) {
+			config := Config{Duration: 400 * time.Millisecond}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			res := httptest.NewRecorder()
+			token := createSessionManager(req, res, cookie.New(req, res, "/"), c, config).MustNew(user)
+			req.AddCookie(&http.Cookie{Name: SessionCookieKey, Value: token})
+			sm := createSessionManager(req, res, cookie.New(req, res, "/"), c, config)
+			created := sm.MustGet().LastSeenAt
+			sm.MustSlide()
+			assert.True(t, created.Equal(sm.MustGet().LastSeenAt))
+			time.Sleep(250 * time.Millisecond)
+			sm.MustSlide()
+			assert.True(t, sm.MustGet().LastSeenAt.After(created))
+			time.Sleep(250 * time.Millisecond)
+			assert.Equal(t, user.Id, sm.MustGet().Id)
+		},
+	)
+	t.Run(
+		"max lifetime", func(t *testing.T) {
+			config := Config{MaxLifetime: 200 * time.Millisecond}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			res := httptest.NewRecorder()
+			token := createSessionManager(req, res, cookie.New(req, res, "/"), c, config).MustNew(user)
+			req.AddCookie(&http.Cookie{Name: SessionCookieKey, Value: token})
+			sm := createSessionManager(req, res, cookie.New(req, res, "/"), c, config)
+			sm.MustRenew()
+			time.Sleep(250 * time.Millisecond)
+			assert.Equal(t, 0, sm.MustGet().Id)
+			assert.ErrorIs(t, sm.Renew(), ErrorMissingSessionCookie)
+		},
+	)
+	t.Run(
+		"resolve permissions", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			res := httptest.NewRecorder()
+			config := Config{Roles: []Role{{Name: "owner", Securables: []string{"invoice.*"}}}}
+			token := createSessionManager(req, res, cookie.New(req, res, "/"), c, config).MustNew(user)
+			config.Roles = []Role{{Name: "owner", Securables: []string{"report.view"}}}
+			session := createSessionManager(req, res, cookie.New(req, res, "/"), c, config).MustGet(token)
+			assert.Equal(t, []string{"report.view"}, session.Permissions)
+			assert.False(t, session.Can("invoice.edit"))
+			config.Roles = []Role{{Name: "owner", Super: true}}
+			session = createSessionManager(req, res, cookie.New(req, res, "/"), c, config).MustGet(token)
+			assert.True(t, session.Super)
+			assert.True(t, session.Can("invoice.edit"))
+		},
+	)
+	t.Run(
+		"legacy session", func(t *testing.T) {
+			root := cache.New(context.Background(), memory.New(t.TempDir()), nil)
+			root.MustSet(createSessionCacheKey("legacy"), Session{Id: user.Id, Email: user.Email}, time.Minute)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.AddCookie(&http.Cookie{Name: SessionCookieKey, Value: "legacy"})
+			res := httptest.NewRecorder()
+			config := Config{LegacyCache: root}
+			sm := createSessionManager(req, res, cookie.New(req, res, "/"), root.Namespace("auth"), config)
+			assert.Equal(t, user.Id, sm.MustGet().Id)
+			assert.False(t, root.Exists(createSessionCacheKey("legacy")))
+			assert.True(t, root.Exists("auth:"+createSessionCacheKey("legacy")))
+			assert.Equal(t, 1, len(sm.MustSessions(user.Id)))
+		},
+	)
+	t.Run(
+		"forwarded ip", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Forwarded-For", "203.0.113.9")
+			res := httptest.NewRecorder()
+			token := createSessionManager(req, res, cookie.New(req, res, "/"), c, Config{}).MustNew(user)
+			session := createSessionManager(req, res, cookie.New(req, res, "/"), c, Config{}).MustGet(token)
+			assert.Equal(t, "192.0.2.1", session.Ip)
+			config := Config{TrustedProxies: []string{"192.0.2.0/24"}}
+			token = createSessionManager(req, res, cookie.New(req, res, "/"), c, config).MustNew(user)
+			session = createSessionManager(req, res, cookie.New(req, res, "/"), c, config).MustGet(token)
+			assert.Equal(t, "203.0.113.9", session.Ip)
+		},
+	)
+}
@@ -0,0 +1,517 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	
+	"github.com/dchest/uniuri"
+	
+	"github.com/daarlabs/arcanum/cache"
+	"github.com/daarlabs/arcanum/cookie"
+)
+
+type Stateless struct {
+	Keys       []SigningKey  `json:"keys" yaml:"keys" toml:"keys"`
+	Issuer     string        `json:"issuer" yaml:"issuer" toml:"issuer"`
+	Expiration time.Duration `json:"expiration" yaml:"expiration" toml:"expiration"`
+}
+
+type SigningKey struct {
+	Id     string `json:"id" yaml:"id" toml:"id"`
+	Secret string `json:"secret" yaml:"secret" toml:"secret"`
+}
+
+type statelessSessionManager struct {
+	sessionManager
+}
+
+type sessionClaims struct {
+	Session
+	Sid string `json:"sid"`
+	Jti string `json:"jti"`
+	Iss string `json:"iss,omitempty"`
+	Iat int64  `json:"iat"`
+	Exp int64  `json:"exp"`
+}
+
+type refreshRecord struct {
+	Session Session `json:"session"`
+	Hash    string  `json:"hash"`
+}
+
+const (
+	SessionModeCache = "cache"
+	SessionModeJwt   = "jwt"
+)
+
+const (
+	RefreshCookieKey           = "X-Session-Refresh"
+	DefaultStatelessExpiration = 15 * time.Minute
+	MinSigningKeyLength        = 32
+)
+
+const (
+	jwtAlgHs256 = "HS256"
+	jwtTyp      = "JWT"
+)
+
+const (
+	refreshGraceDuration = 30 * time.Second
+)
+
+func createStatelessSessionManager(
+	req *http.Request,
+	res http.ResponseWriter,
+	cookie cookie.Cookie,
+	cache cache.Client,
+	config Config,
+) SessionManager {
+	return &statelessSessionManager{
+		sessionManager: sessionManager{
+			req:    req,
+			res:    res,
+			cookie: cookie,
+			cache:  cache,
+			config: config,
+		},
+	}
+}
+
+func (s statelessSessionManager) Token() string {
+	if token := s.cookie.Get(SessionCookieKey); len(token) > 0 {
+		return token
+	}
+	if bearer := GetBearerToken(s.req); !strings.HasPrefix(bearer, TokenPrefix) {
+		return bearer
+	}
+	return ""
+}
+
+func (s statelessSessionManager) Exists() (bool, error) {
+	session, err := s.Get()
+	if err != nil {
+		return false, err
+	}
+	return session.Id > 0, nil
+}
+
+func (s statelessSessionManager) MustExists() bool {
+	exists, err := s.Exists()
+	if err != nil {
+		panic(err)
+	}
+	return exists
+}
+
+func (s statelessSessionManager) Get(token ...string) (Session, error) {
+	if len(token) > 0 {
+		claims, err := s.verify(token[0])
+		if err != nil {
+			return Session{}, nil
+		}
+		return claims.Session, nil
+	}
+	if t := s.Token(); len(t) > 0 {
+		if claims, err := s.verify(t); err == nil {
+			return claims.Session, nil
+		}
+	}
+	if len(s.cookie.Get(RefreshCookieKey)) > 0 {
+		if session, err := s.refresh(); err != nil || session.Id > 0 {
+			return session, err
+		}
+	}
+	if bearer := GetBearerToken(s.req); s.tokens != nil && s.config.Bearer && strings.HasPrefix(bearer, TokenPrefix) {
+		return s.tokens.Verify(bearer)
+	}
+	if s.restore != nil {
+		t, err := s.restore()
+		if err != nil || len(t) == 0 {
+			return Session{}, err
+		}
+		claims, err := s.verify(t)
+		if err != nil {
+			return Session{}, nil
+		}
+		return claims.Session, nil
+	}
+	return Session{}, nil
+}
+
+func (s statelessSessionManager) MustGet(token ...string) Session {
+	r, err := s.Get(token...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (s statelessSessionManager) New(user User) (string, error) {
+	session := s.createSession(uniuri.New(), user)
+	secret := uniuri.NewLen(TokenLength)
+	if err := s.storeRefresh(session, secret); err != nil {
+		return "", err
+	}
+	return s.issue(session)
+}
+
+func (s statelessSessionManager) MustNew(user User) string {
+	token, err := s.New(user)
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
+func (s statelessSessionManager) Renew() error {
+	if t := s.Token(); len(t) > 0 {
+		claims, err := s.verify(t)
+		if err == nil && time.Until(time.Unix(claims.Exp, 0)) > s.expiration()/2 {
+			if claims.Ip != s.getIp() || claims.UserAgent != s.getUserAgent() {
+				return ErrorCredentialsMismatch
+			}
+			return nil
+		}
+	}
+	if len(s.cookie.Get(RefreshCookieKey)) == 0 {
+		return ErrorMissingSessionCookie
+	}
+	session, err := s.refresh()
+	if err != nil {
+		return err
+	}
+	if session.Id == 0 || session.Ip != s.getIp() || session.UserAgent != s.getUserAgent() {
+		return ErrorCredentialsMismatch
+	}
+	s.renewed(session)
+	return nil
+}
+
+func (s statelessSessionManager) MustRenew() {
+	if err := s.Renew(); err != nil {
+		panic(err)
+	}
+}
+
+func (s statelessSessionManager) Slide() error {
+	return s.Renew()
+}
+
+func (s statelessSessionManager) MustSlide() {
+	if err := s.Slide(); err != nil {
+		panic(err)
+	}
+}
+
+func (s statelessSessionManager) Destroy() error {
+	sid := s.currentSid()
+	s.cookie.Set(SessionCookieKey, "", time.Millisecond)
+	s.cookie.Set(RefreshCookieKey, "", time.Millisecond)
+	return s.revoke(sid)
+}
+
+func (s statelessSessionManager) MustDestroy() {
+	if err := s.Destroy(); err != nil {
+		panic(err)
+	}
+}
+
+func (s statelessSessionManager) Sessions(userId int) ([]Session, error) {
+	sids, err := s.getUserTokens(userId)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(sids))
+	for i, sid := range sids {
+		keys[i] = createSessionRefreshCacheKey(sid)
+	}
+	records := make(map[string]refreshRecord)
+	if err := s.cache.GetMany(keys, &records); err != nil {
+		return nil, err
+	}
+	result := make([]Session, 0, len(records))
+	stale := make([]string, 0)
+	for _, sid := range sids {
+		record, ok := records[createSessionRefreshCacheKey(sid)]
+		if !ok || record.Session.Id != userId {
+			stale = append(stale, createSessionUserCacheKey(userId, sid))
+			continue
+		}
+		session := record.Session
+		session.Token, session.Device = "", hashToken(sid)
+		result = append(result, session)
+	}
+	slices.SortFunc(
+		result, func(a, b Session) int {
+			return b.LastSeenAt.Compare(a.LastSeenAt)
+		},
+	)
+	return result, s.cache.DestroyMany(stale...)
+}
+
+func (s statelessSessionManager) MustSessions(userId int) []Session {
+	sessions, err := s.Sessions(userId)
+	if err != nil {
+		panic(err)
+	}
+	return sessions
+}
+
+func (s statelessSessionManager) Revoke(userId int, device string) error {
+	sids, err := s.getUserTokens(userId)
+	if err != nil {
+		return err
+	}
+	for _, sid := range sids {
+		if hashToken(sid) == device {
+			return s.revoke(sid)
+		}
+	}
+	return nil
+}
+
+func (s statelessSessionManager) MustRevoke(userId int, device string) {
+	if err := s.Revoke(userId, device); err != nil {
+		panic(err)
+	}
+}
+
+func (s statelessSessionManager) revoke(sid string) error {
+	if len(sid) == 0 {
+		return nil
+	}
+	var record refreshRecord
+	if err := s.cache.Get(createSessionRefreshCacheKey(sid), &record); err != nil {
+		return err
+	}
+	if err := s.cache.Set(createSessionDenyCacheKey(sid), true, s.expiration()); err != nil {
+		return err
+	}
+	keys := []string{createSessionRefreshCacheKey(sid)}
+	if record.Session.Id > 0 {
+		keys = append(keys, createSessionUserCacheKey(record.Session.Id, sid))
+	}
+	if err := s.cache.DestroyMany(keys...); err != nil {
+		return err
+	}
+	if err := s.cache.DestroyPrefix(createSessionRefreshGraceCacheKey(sid, "")); err != nil {
+		return err
+	}
+	return s.cache.DestroyPrefix(createSessionRefreshUsedCacheKey(sid, ""))
+}
+
+func (s statelessSessionManager) RevokeAll(userId int, exceptCurrent bool) error {
+	sids, err := s.getUserTokens(userId)
+	if err != nil {
+		return err
+	}
+	current := s.currentSid()
+	for _, sid := range sids {
+		if exceptCurrent && sid == current {
+			continue
+		}
+		if err := s.revoke(sid); err != nil {
+			return err
+		}
+	}
+	return s.forgetAll(userId, exceptCurrent)
+}
+
+func (s statelessSessionManager) MustRevokeAll(userId int, exceptCurrent bool) {
+	if err := s.RevokeAll(userId, exceptCurrent); err != nil {
+		panic(err)
+	}
+}
+
+func (s statelessSessionManager) verify(token string) (sessionClaims, error) {
+	claims, err := parseSessionClaims(token, s.config.Stateless)
+	if err != nil {
+		return claims, err
+	}
+	if s.cache.Exists(createSessionDenyCacheKey(claims.Sid)) {
+		return sessionClaims{}, ErrorInvalidToken
+	}
+	claims.Session = s.resolve(claims.Session)
+	return claims, nil
+}
+
+func (s statelessSessionManager) refresh() (Session, error) {
+	sid, secret, ok := strings.Cut(s.cookie.Get(RefreshCookieKey), ".")
+	if !ok || len(sid) == 0 || len(secret) == 0 {
+		return Session{}, nil
+	}
+	var record refreshRecord
+	if err := s.cache.Get(createSessionRefreshCacheKey(sid), &record); err != nil {
+		return Session{}, err
+	}
+	if record.Session.Id == 0 {
+		s.cookie.Set(RefreshCookieKey, "", time.Millisecond)
+		return Session{}, nil
+	}
+	hash := hashToken(secret)
+	if !hmac.Equal([]byte(record.Hash), []byte(hash)) {
+		var rotated string
+		if err := s.cache.Get(createSessionRefreshGraceCacheKey(sid, hash), &rotated); err != nil {
+			return Session{}, err
+		}
+		if len(rotated) > 0 {
+			s.cookie.Set(RefreshCookieKey, sid+"."+rotated, s.ttl(record.Session), cookie.HttpOnly())
+			if _, err := s.issue(record.Session); err != nil {
+				return Session{}, err
+			}
+			return s.resolve(record.Session), nil
+		}
+		if !s.cache.Exists(createSessionRefreshUsedCacheKey(sid, hash)) {
+			return Session{}, nil
+		}
+		s.cookie.Set(SessionCookieKey, "", time.Millisecond)
+		s.cookie.Set(RefreshCookieKey, "", time.Millisecond)
+		return Session{}, s.revoke(sid)
+	}
+	if s.ttl(record.Session) <= 0 {
+		s.cookie.Set(RefreshCookieKey, "", time.Millisecond)
+		return Session{}, s.revoke(sid)
+	}
+	session := record.Session
+	session.LastSeenAt = time.Now()
+	if err := s.cache.Set(createSessionRefreshUsedCacheKey(sid, hash), true, s.ttl(session)); err != nil {
+		return Session{}, err
+	}
+	rotated := uniuri.NewLen(TokenLength)
+	if err := s.storeRefresh(session, rotated); err != nil {
+		return Session{}, err
+	}
+	if err := s.cache.Set(createSessionRefreshGraceCacheKey(sid, hash), rotated, refreshGraceDuration); err != nil {
+		return Session{}, err
+	}
+	if _, err := s.issue(session); err != nil {
+		return Session{}, err
+	}
+	return s.resolve(session), nil
+}
+
+func (s statelessSessionManager) issue(session Session) (string, error) {
+	if err := ValidateStateless(s.config.Stateless); err != nil {
+		return "", err
+	}
+	t := time.Now()
+	claims := sessionClaims{
+		Session: session,
+		Sid:     session.Token,
+		Jti:     uniuri.New(),
+		Iss:     s.config.Stateless.Issuer,
+		Iat:     t.Unix(),
+		Exp:     t.Add(s.expiration()).Unix(),
+	}
+	claims.Session.Token = ""
+	token, err := signJwt(s.config.Stateless.Keys[0], claims)
+	if err != nil {
+		return "", err
+	}
+	s.cookie.Set(SessionCookieKey, token, s.expiration())
+	return token, nil
+}
+
+func (s statelessSessionManager) storeRefresh(session Session, secret string) error {
+	duration := s.ttl(session)
+	record := refreshRecord{Session: session, Hash: hashToken(secret)}
+	if err := s.cache.Set(createSessionRefreshCacheKey(session.Token), record, duration); err != nil {
+		return err
+	}
+	if err := s.cache.Set(
+		createSessionUserCacheKey(session.Id, session.Token), session.Token, duration,
+	); err != nil {
+		return err
+	}
+	s.cookie.Set(RefreshCookieKey, session.Token+"."+secret, duration, cookie.HttpOnly())
+	return nil
+}
+
+func (s statelessSessionManager) currentSid() string {
+	if t := s.Token(); len(t) > 0 {
+		if claims, err := parseSessionClaims(t, s.config.Stateless); err == nil {
+			return claims.Sid
+		}
+	}
+	sid, _, _ := strings.Cut(s.cookie.Get(RefreshCookieKey), ".")
+	return sid
+}
+
+func (s statelessSessionManager) expiration() time.Duration {
+	if s.config.Stateless.Expiration == 0 {
+		return DefaultStatelessExpiration
+	}
+	return s.config.Stateless.Expiration
+}
+
+func ValidateStateless(config Stateless) error {
+	if len(config.Keys) == 0 {
+		return ErrorMissingSecret
+	}
+	for _, key := range config.Keys {
+		if len(key.Secret) < MinSigningKeyLength {
+			return ErrorSecretTooShort
+		}
+	}
+	return nil
+}
+
+func ParseSessionToken(token string, config Stateless) (Session, error) {
+	claims, err := parseSessionClaims(token, config)
+	if err != nil {
+		return Session{}, err
+	}
+	return claims.Session, nil
+}
+
+func parseSessionClaims(token string, config Stateless) (sessionClaims, error) {
+	var claims sessionClaims
+	t, err := parseJwt(token)
+	if err != nil {
+		return claims, err
+	}
+	index := slices.IndexFunc(
+		config.Keys, func(key SigningKey) bool {
+			return key.Id == t.header.Kid
+		},
+	)
+	if index == -1 || t.header.Alg != jwtAlgHs256 || len(config.Keys[index].Secret) < MinSigningKeyLength {
+		return claims, ErrorInvalidToken
+	}
+	if !hmac.Equal(t.signature, signHs256(config.Keys[index].Secret, t.input)) {
+		return claims, ErrorInvalidToken
+	}
+	if err := json.Unmarshal(t.payload, &claims); err != nil {
+		return claims, ErrorInvalidToken
+	}
+	if claims.Exp <= time.Now().Unix() || len(claims.Sid) == 0 || claims.Iss != config.Issuer {
+		return claims, ErrorInvalidToken
+	}
+	claims.Session.Token = claims.Sid
+	return claims, nil
+}
+
+func signJwt(key SigningKey, claims any) (string, error) {
+	header, err := json.Marshal(jwtHeader{Alg: jwtAlgHs256, Kid: key.Id, Typ: jwtTyp})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return input + "." + base64.RawURLEncoding.EncodeToString(signHs256(key.Secret, input)), nil
+}
+
+func signHs256(secret, input string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(input))
+	return mac.Sum(nil)
+}
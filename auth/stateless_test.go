@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	
+	"github.com/stretchr/testify/assert"
+	
+	"github.com/daarlabs/arcanum/cache"
+	"github.com/daarlabs/arcanum/cache/memory"
+	"github.com/daarlabs/arcanum/cookie"
+)
+
+func TestStatelessSession(t *testing.T) {
+	c := cache.New(context.Background(), memory.New(t.TempDir()), nil).Namespace("auth")
+	user := User{Id: 1, Email: "dominik@linduska.dev", Roles: []string{"owner"}}
+	config := Config{
+		Mode:  SessionModeJwt,
+		Roles: []Role{{Name: "owner", Super: true}},
+		Stateless: Stateless{
+			Keys: []SigningKey{
+				{Id: "current", Secret: "current-secret-0123456789abcdefghij"},
+				{Id: "previous", Secret: "previous-secret-0123456789abcdefghij"},
+			},
+		},
+	}
+	createTestSessionManager := func(config Config, cookies ...*http.Cookie) (SessionManager, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("User-Agent", "desktop")
+		for _, item := range cookies {
+			req.AddCookie(item)
+		}
+		res := httptest.NewRecorder()
+		return createStatelessSessionManager(req, res, cookie.New(req, res, "/"), c, config), res
+	}
+	getCookie := func(res *httptest.ResponseRecorder, name string) *http.Cookie {
+		var r *http.Cookie
+		for _, item := range res.Result().Cookies() {
+			if item.Name == name {
+				r = item
+			}
+		}
+		return r
+	}
+	sm, res := createTestSessionManager(config)
+	token := sm.MustNew(user)
+	refresh := getCookie(res, RefreshCookieKey)
+	t.Run(
+		"verify", func(t *testing.T) {
+			sm, _ := createTestSessionManager(config, &http.Cookie{Name: SessionCookieKey, Value: token})
+			session := sm.MustGet()
+			assert.Equal(t, user.Id, session.Id)
+			assert.True(t, session.Super)
+			assert.Equal(t, "desktop", session.UserAgent)
+			sessions := sm.MustSessions(user.Id)
+			assert.Len(t, sessions, 1)
+			assert.Empty(t, sessions[0].Token)
+			assert.Equal(t, hashToken(session.Token), sessions[0].Device)
+			parsed, err := ParseSessionToken(token, config.Stateless)
+			assert.NoError(t, err)
+			assert.Equal(t, session.Token, parsed.Token)
+			_, err = ParseSessionToken(token+"x", config.Stateless)
+			assert.ErrorIs(t, err, ErrorInvalidToken)
+		},
+	)
+	t.Run(
+		"rotation", func(t *testing.T) {
+			rotated := config
+			rotated.Stateless.Keys = []SigningKey{{Id: "next", Secret: "next-secret-0123456789abcdefghij"}, config.Stateless.Keys[0]}
+			sm, _ := createTestSessionManager(rotated, &http.Cookie{Name: SessionCookieKey, Value: token})
+			assert.Equal(t, user.Id, sm.MustGet().Id)
+			next := sm.MustNew(user)
+			sm, _ = createTestSessionManager(config, &http.Cookie{Name: SessionCookieKey, Value: next})
+			assert.Equal(t, 0, sm.MustGet().Id)
+		},
+	)
+	t.Run(
+		"refresh", func(t *testing.T) {
+			expiring := config
+			expiring.Stateless.Expiration = time.Second
+			sm, res := createTestSessionManager(expiring)
+			expired := sm.MustNew(user)
+			refresh := getCookie(res, RefreshCookieKey)
+			time.Sleep(1100 * time.Millisecond)
+			sm, _ = createTestSessionManager(expiring, &http.Cookie{Name: SessionCookieKey, Value: expired})
+			assert.Equal(t, 0, sm.MustGet().Id)
+			sm, res = createTestSessionManager(
+				expiring,
+				&http.Cookie{Name: SessionCookieKey, Value: expired},
+				&http.Cookie{Name: RefreshCookieKey, Value: refresh.Value},
+			)
+			assert.NoError(t, sm.Renew())
+			renewed := getCookie(res, SessionCookieKey)
+			assert.NotNil(t, renewed)
+			assert.NotEqual(t, expired, renewed.Value)
+			sm, _ = createTestSessionManager(
+				expiring, &http.Cookie{Name: RefreshCookieKey, Value: strings.Split(refresh.Value, ".")[0] + ".invalid"},
+			)
+			assert.Equal(t, 0, sm.MustGet().Id)
+		},
+	)
+	t.Run(
+		"refresh reuse", func(t *testing.T) {
+			sm, res := createTestSessionManager(config)
+			sm.MustNew(user)
+			original := getCookie(res, RefreshCookieKey)
+			sm, res = createTestSessionManager(config, &http.Cookie{Name: RefreshCookieKey, Value: original.Value})
+			assert.Equal(t, user.Id, sm.MustGet().Id)
+			rotated := getCookie(res, RefreshCookieKey)
+			access := getCookie(res, SessionCookieKey)
+			assert.NotEqual(t, original.Value, rotated.Value)
+			sid, secret, _ := strings.Cut(original.Value, ".")
+			assert.Equal(t, sid, strings.Split(rotated.Value, ".")[0])
+			sm, res = createTestSessionManager(config, &http.Cookie{Name: RefreshCookieKey, Value: original.Value})
+			assert.Equal(t, user.Id, sm.MustGet().Id)
+			assert.Equal(t, rotated.Value, getCookie(res, RefreshCookieKey).Value)
+			c.MustDestroy(createSessionRefreshGraceCacheKey(sid, hashToken(secret)))
+			sm, _ = createTestSessionManager(config, &http.Cookie{Name: RefreshCookieKey, Value: original.Value})
+			assert.Equal(t, 0, sm.MustGet().Id)
+			sm, _ = createTestSessionManager(config, &http.Cookie{Name: RefreshCookieKey, Value: rotated.Value})
+			assert.Equal(t, 0, sm.MustGet().Id)
+			sm, _ = createTestSessionManager(config, &http.Cookie{Name: SessionCookieKey, Value: access.Value})
+			assert.Equal(t, 0, sm.MustGet().Id)
+		},
+	)
+	t.Run(
+		"short secret", func(t *testing.T) {
+			short := config
+			short.Stateless.Keys = []SigningKey{{Id: "short", Secret: "secret"}}
+			assert.ErrorIs(t, ValidateStateless(short.Stateless), ErrorSecretTooShort)
+			assert.ErrorIs(t, ValidateStateless(Stateless{}), ErrorMissingSecret)
+			assert.NoError(t, ValidateStateless(config.Stateless))
+			sm, _ := createTestSessionManager(short)
+			_, err := sm.New(user)
+			assert.ErrorIs(t, err, ErrorSecretTooShort)
+			token, err := signJwt(short.Stateless.Keys[0], sessionClaims{Sid: "sid", Exp: time.Now().Add(time.Minute).Unix()})
+			assert.NoError(t, err)
+			_, err = ParseSessionToken(token, short.Stateless)
+			assert.ErrorIs(t, err, ErrorInvalidToken)
+		},
+	)
+	t.Run(
+		"revoke", func(t *testing.T) {
+			sm, res := createTestSessionManager(
+				config,
+				&http.Cookie{Name: SessionCookieKey, Value: token},
+				&http.Cookie{Name: RefreshCookieKey, Value: refresh.Value},
+			)
+			sm.MustRevokeAll(user.Id, false)
+			assert.Equal(t, 0, sm.MustGet().Id)
+			assert.Len(t, sm.MustSessions(user.Id), 0)
+			assert.Nil(t, getCookie(res, SessionCookieKey))
+		},
+	)
+}
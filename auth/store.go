@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	
+	"github.com/daarlabs/arcanum/quirk"
+)
+
+type UserStore interface {
+	Get(id int, email string) (User, error)
+	Create(data map[string]any) (int, error)
+	Update(id int, email string, data map[string]any, conditions ...map[string]any) (bool, error)
+}
+
+type UserSchema struct {
+	Table   string            `json:"table" yaml:"table" toml:"table"`
+	Columns map[string]string `json:"columns" yaml:"columns" toml:"columns"`
+}
+
+type quirkUserStore struct {
+	db     *quirk.DB
+	schema UserSchema
+}
+
+const (
+	UserColumnDisabled = "-"
+)
+
+var (
+	userColumns = []string{
+		quirk.Id,
+		UserActive,
+		UserRoles,
+		UserEmail,
+		UserPassword,
+		UserTfa,
+		UserTfaSecret,
+		UserTfaCodes,
+		UserTfaUrl,
+		UserEmailVerifiedAt,
+		UserFirstName,
+		UserLastName,
+		UserLocale,
+		UserTenantId,
+		UserLastActivity,
+		quirk.CreatedAt,
+		quirk.UpdatedAt,
+	}
+)
+
+func CreateUserStore(db *quirk.DB, schema UserSchema) UserStore {
+	return &quirkUserStore{
+		db:     db,
+		schema: schema,
+	}
+}
+
+func (s *quirkUserStore) Get(id int, email string) (User, error) {
+	var r User
+	if id == 0 && email == "" {
+		return r, ErrorInvalidUser
+	}
+	err := quirk.New(s.db).Q(fmt.Sprintf(`SELECT %s`, s.schema.selectColumns("", userColumns...))).
+		Q(fmt.Sprintf(`FROM %s`, s.schema.table())).
+		Q(s.where(id, email)).
+		Q(`LIMIT 1`).
+		Exec(&r)
+	return r, err
+}
+
+func (s *quirkUserStore) Create(data map[string]any) (int, error) {
+	var id int
+	data = s.filter(data)
+	columns, placeholders := make([]string, 0), make([]string, 0)
+	if _, ok := data[quirk.Id]; !ok {
+		columns, placeholders = append(columns, s.schema.column(quirk.Id)), append(placeholders, quirk.Default)
+	}
+	for name := range data {
+		columns = append(columns, s.schema.column(name))
+		placeholders = append(placeholders, paramPrefix+name)
+	}
+	if s.hasVectors() && len(data) > 0 {
+		data[quirk.Vectors] = s.vectors(data)
+		columns = append(columns, s.schema.column(quirk.Vectors))
+		placeholders = append(placeholders, fmt.Sprintf("to_tsvector(%s%s)", paramPrefix, quirk.Vectors))
+	}
+	for _, column := range []string{UserLastActivity, quirk.CreatedAt, quirk.UpdatedAt} {
+		if !s.schema.enabled(column) {
+			continue
+		}
+		columns = append(columns, s.schema.column(column))
+		placeholders = append(placeholders, quirk.CurrentTimestamp)
+	}
+	q := quirk.New(s.db).Q(fmt.Sprintf(`INSERT INTO %s`, s.schema.table())).
+		Q(fmt.Sprintf(`(%s)`, strings.Join(columns, ","))).
+		Q(fmt.Sprintf(`VALUES (%s)`, strings.Join(placeholders, ",")), data)
+	if s.db.DriverName() == quirk.Mysql {
+		r, err := q.Result()
+		if err != nil {
+			return id, err
+		}
+		n, err := r.LastInsertId()
+		return int(n), err
+	}
+	err := q.Q(fmt.Sprintf(`RETURNING %s`, s.schema.column(quirk.Id))).Exec(&id)
+	return id, err
+}
+
+func (s *quirkUserStore) Update(id int, email string, data map[string]any, conditions ...map[string]any) (bool, error) {
+	if id == 0 && email == "" {
+		return false, ErrorInvalidUser
+	}
+	data = s.filter(data)
+	values := make([]string, 0)
+	for column := range data {
+		if column == quirk.Id {
+			continue
+		}
+		values = append(values, fmt.Sprintf("%s = %s%s", s.schema.column(column), paramPrefix, column))
+	}
+	for _, column := range []string{UserLastActivity, quirk.UpdatedAt} {
+		if !s.schema.enabled(column) {
+			continue
+		}
+		values = append(values, fmt.Sprintf("%s = %s", s.schema.column(column), quirk.CurrentTimestamp))
+	}
+	if s.hasVectors() && len(data) > 0 {
+		data[quirk.Vectors] = s.vectors(data)
+		values = append(
+			values,
+			fmt.Sprintf("%s = to_tsvector(%s%s)", s.schema.column(quirk.Vectors), paramPrefix, quirk.Vectors),
+		)
+	}
+	q := quirk.New(s.db).Q(fmt.Sprintf(`UPDATE %s`, s.schema.table())).
+		Q(fmt.Sprintf(`SET %s`, strings.Join(values, ",")), data).
+		Q(s.where(id, email))
+	for i, condition := range conditions {
+		for column, value := range condition {
+			name := fmt.Sprintf("condition-%d-%s", i, column)
+			q.Q(fmt.Sprintf(`AND %s = %s%s`, s.schema.column(column), paramPrefix, name), quirk.Map{name: value})
+		}
+	}
+	if s.db.DriverName() == quirk.Mysql {
+		r, err := q.Result()
+		if err != nil {
+			return false, err
+		}
+		n, err := r.RowsAffected()
+		return n > 0, err
+	}
+	var r int
+	err := q.Q(fmt.Sprintf(`RETURNING %s`, s.schema.column(quirk.Id))).Exec(&r)
+	return r > 0, err
+}
+
+func (s *quirkUserStore) where(id int, email string) (string, quirk.Map) {
+	if id > 0 {
+		return fmt.Sprintf(`WHERE %s = @id`, s.schema.column(quirk.Id)), quirk.Map{"id": id}
+	}
+	return fmt.Sprintf(`WHERE %s = @email`, s.schema.column(UserEmail)), quirk.Map{"email": email}
+}
+
+func (s *quirkUserStore) filter(data map[string]any) quirk.Map {
+	result := make(quirk.Map)
+	for column, value := range data {
+		if !s.schema.enabled(column) {
+			continue
+		}
+		result[column] = value
+	}
+	return result
+}
+
+func (s *quirkUserStore) hasVectors() bool {
+	return s.db.DriverName() == quirk.Postgres && s.schema.enabled(quirk.Vectors)
+}
+
+func (s *quirkUserStore) vectors(data map[string]any) string {
+	vectors := make([]any, 0)
+	for name, v := range data {
+		kind := reflect.TypeOf(v).Kind()
+		if _, safe := v.(quirk.Safe); safe || name == UserPassword || kind == reflect.Bool || kind == reflect.Struct {
+			continue
+		}
+		vectors = append(vectors, v)
+	}
+	return quirk.CreateTsVector(vectors...)
+}
+
+func (s UserSchema) table() string {
+	if len(s.Table) == 0 {
+		return usersTable
+	}
+	return s.Table
+}
+
+func (s UserSchema) column(name string) string {
+	if column, ok := s.Columns[name]; ok && len(column) > 0 {
+		return column
+	}
+	return name
+}
+
+func (s UserSchema) enabled(name string) bool {
+	return s.column(name) != UserColumnDisabled
+}
+
+func (s UserSchema) selectColumns(alias string, columns ...string) string {
+	if len(alias) > 0 {
+		alias += "."
+	}
+	result := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if !s.enabled(column) {
+			continue
+		}
+		if mapped := s.column(column); mapped != column {
+			result = append(result, fmt.Sprintf("%s%s AS %s", alias, mapped, column))
+			continue
+		}
+		result = append(result, alias+column)
+	}
+	return strings.Join(result, ", ")
+}
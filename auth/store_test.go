@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	
+	"github.com/daarlabs/arcanum/cache"
+	"github.com/daarlabs/arcanum/cache/memory"
+	"github.com/daarlabs/arcanum/cookie"
+	"github.com/daarlabs/arcanum/quirk"
+)
+
+type testUserStore struct {
+	users map[int]User
+}
+
+func (s *testUserStore) Get(id int, email string) (User, error) {
+	for _, user := range s.users {
+		if (id > 0 && user.Id == id) || (id == 0 && user.Email == email) {
+			return user, nil
+		}
+	}
+	return User{}, nil
+}
+
+func (s *testUserStore) Create(data map[string]any) (int, error) {
+	user := User{Id: len(s.users) + 1}
+	s.users[user.Id] = user
+	_, err := s.Update(user.Id, "", data)
+	return user.Id, err
+}
+
+func (s *testUserStore) Update(id int, email string, data map[string]any, conditions ...map[string]any) (bool, error) {
+	user, err := s.Get(id, email)
+	if err != nil || user.Id == 0 {
+		return false, err
+	}
+	for _, condition := range conditions {
+		if v, ok := condition[UserActive]; ok && v != user.Active {
+			return false, nil
+		}
+		if v, ok := condition[UserTfaCodes]; ok && v != user.TfaCodes.V {
+			return false, nil
+		}
+	}
+	for column, value := range data {
+		switch column {
+		case UserActive:
+			user.Active = value.(bool)
+		case UserRoles:
+			user.Roles = value.([]string)
+		case UserEmail:
+			user.Email = value.(string)
+		case UserPassword:
+			user.Password = value.(string)
+		case UserFirstName:
+			user.FirstName = value.(string)
+		case UserLocale:
+			user.Locale = value.(string)
+		case UserTenantId:
+			user.TenantId = value.(int)
+		case UserTfa:
+			user.Tfa = value.(bool)
+		case UserTfaCodes:
+			if codes, ok := value.(string); ok {
+				user.TfaCodes = sql.Null[string]{V: codes, Valid: true}
+			} else {
+				user.TfaCodes = sql.Null[string]{}
+			}
+		}
+	}
+	s.users[user.Id] = user
+	return true, nil
+}
+
+func TestUserStore(t *testing.T) {
+	t.Run(
+		"schema", func(t *testing.T) {
+			schema := UserSchema{
+				Table:   "accounts",
+				Columns: map[string]string{UserEmail: "mail", quirk.Vectors: UserColumnDisabled},
+			}
+			assert.Equal(t, "accounts", schema.table())
+			assert.Equal(t, "users", UserSchema{}.table())
+			assert.Equal(t, "mail", schema.column(UserEmail))
+			assert.Equal(t, UserRoles, schema.column(UserRoles))
+			assert.False(t, schema.enabled(quirk.Vectors))
+			assert.Equal(t, "u.id, u.mail AS email", schema.selectColumns("u", quirk.Id, UserEmail, quirk.Vectors))
+		},
+	)
+	t.Run(
+		"custom store", func(t *testing.T) {
+			store := &testUserStore{users: make(map[int]User)}
+			CreateCustomUserManager(store, nil, 0, "").MustCreate(
+				User{
+					Active:    true,
+					Email:     "dominik@linduska.dev",
+					Password:  "123456789",
+					Roles:     []string{"owner"},
+					FirstName: "Dominik",
+					Locale:    "cs",
+					TenantId:  7,
+				},
+			)
+			c := cache.New(context.Background(), memory.New(t.TempDir()), nil)
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			res := httptest.NewRecorder()
+			m := New(nil, req, res, cookie.New(req, res, "/"), c, Config{UserStore: store})
+			in := m.MustIn("dominik@linduska.dev", "123456789")
+			assert.True(t, in.Ok)
+			session := m.Session().MustGet(in.Token)
+			assert.Equal(t, "Dominik", session.FirstName)
+			assert.Equal(t, "cs", session.Locale)
+			assert.Equal(t, 7, session.TenantId)
+			m.CustomUser(1, "").MustDisable()
+			_, err := m.In("dominik@linduska.dev", "123456789")
+			assert.ErrorIs(t, err, ErrorInvalidCredentials)
+		},
+	)
+	t.Run(
+		"mysql store", func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			store := CreateUserStore(quirk.Wrap(conn, quirk.Mysql), UserSchema{Table: "accounts"})
+			mock.ExpectExec(`INSERT INTO accounts .* VALUES \(DEFAULT,\?,`).
+				WithArgs("dominik@linduska.dev").
+				WillReturnResult(sqlmock.NewResult(7, 1))
+			id, err := store.Create(map[string]any{UserEmail: "dominik@linduska.dev"})
+			assert.NoError(t, err)
+			assert.Equal(t, 7, id)
+			mock.ExpectExec(`UPDATE accounts SET locale = \?,.* WHERE id = \?`).
+				WithArgs("cs", 7).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			ok, err := store.Update(7, "", map[string]any{UserLocale: "cs"})
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			mock.ExpectExec(`UPDATE accounts`).
+				WithArgs("cs", 8).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			ok, err = store.Update(8, "", map[string]any{UserLocale: "cs"})
+			assert.NoError(t, err)
+			assert.False(t, ok)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		},
+	)
+	t.Run(
+		"migration", func(t *testing.T) {
+			schema := UserSchema{
+				Table:   "accounts",
+				Columns: map[string]string{UserLocale: "lang", UserTenantId: UserColumnDisabled},
+			}
+			fields := createUserFields(quirk.Wrap(nil, quirk.Postgres), schema)
+			assert.Equal(t, quirk.Id, fields[0].Name)
+			assert.True(t, slices.ContainsFunc(fields, func(f quirk.Field) bool { return f.Name == "lang" }))
+			assert.False(t, slices.ContainsFunc(fields, func(f quirk.Field) bool { return f.Name == UserTenantId }))
+			conn, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			rows := sqlmock.NewRows([]string{"column_name"})
+			for _, f := range mysqlUserFields {
+				if f.Name != UserFirstName && f.Name != UserLocale {
+					rows.AddRow(f.Name)
+				}
+			}
+			mock.ExpectQuery(`SELECT column_name FROM information_schema.columns`).WithArgs("accounts").WillReturnRows(rows)
+			mock.ExpectQuery(`ALTER TABLE accounts ADD COLUMN first_name varchar`).WillReturnRows(sqlmock.NewRows(nil))
+			mock.ExpectQuery(`ALTER TABLE accounts ADD COLUMN lang varchar`).WillReturnRows(sqlmock.NewRows(nil))
+			mock.ExpectQuery(`ALTER TABLE accounts MODIFY COLUMN tfa_codes text`).WillReturnRows(sqlmock.NewRows(nil))
+			assert.NoError(t, migrateUserTable(quirk.Wrap(conn, quirk.Mysql), schema))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		},
+	)
+}
@@ -2,9 +2,18 @@ package auth
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"image/png"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 	
 	"github.com/dchest/uniuri"
@@ -24,7 +33,14 @@ type TfaManager interface {
 	Enable(id ...int) error
 	Disable(id ...int) error
 	Verify(otp string) (string, error)
+	VerifyCode(code string) (string, error)
 	VerifyCodes(email, codes string) (bool, error)
+	GenerateCodes(id ...int) ([]string, error)
+	RemainingCodes(id ...int) (int, error)
+	SendOtp() error
+	VerifyOtp(otp string) (string, error)
+	TrustDevice() error
+	ForgetDevice() error
 	CreateQrImageBase64(id ...int) (string, error)
 	
 	MustGetPendingUserId() int
@@ -33,10 +49,23 @@ type TfaManager interface {
 	MustEnable(id ...int)
 	MustDisable(id ...int)
 	MustVerify(otp string) string
+	MustVerifyCode(code string) string
 	MustVerifyCodes(email, codes string) bool
+	MustGenerateCodes(id ...int) []string
+	MustRemainingCodes(id ...int) int
+	MustSendOtp()
+	MustVerifyOtp(otp string) string
+	MustTrustDevice()
+	MustForgetDevice()
 	MustCreateQrImageBase64(id ...int) string
 }
 
+type Tfa struct {
+	Codes         int           `json:"codes" yaml:"codes" toml:"codes"`
+	TrustDuration time.Duration `json:"trustDuration" yaml:"trustDuration" toml:"trustDuration"`
+	Secret        string        `json:"secret" yaml:"secret" toml:"secret"`
+}
+
 type tfaManager struct {
 	manager *manager
 	db      *quirk.DB
@@ -45,12 +74,23 @@ type tfaManager struct {
 }
 
 const (
-	TfaCookieKey = "X-Tfa"
-	TfaImageSize = 200
+	TfaCookieKey      = "X-Tfa"
+	TfaTrustCookieKey = "X-Tfa-Trust"
+	TfaImageSize      = 200
 )
 
 const (
-	tfaSecretCodesLength = 160
+	DefaultTfaCodes      = 8
+	DefaultTrustDuration = 30 * 24 * time.Hour
+)
+
+const (
+	tfaCodeLength     = 10
+	tfaCodeSaltLength = 16
+	tfaCodeChars      = "abcdefghijklmnopqrstuvwxyz0123456789"
+	tfaCodesSeparator = ";"
+	tfaOtpLength      = 6
+	tfaTrustSeparator = ":"
 )
 
 func createTfaManager(
@@ -101,7 +141,7 @@ func (m tfaManager) GetActive() (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	return user.Tfa && len(user.TfaUrl.V) > 0 && len(user.TfaCodes.V) > 0 && len(user.TfaSecret.V) > 0, nil
+	return user.Tfa && len(user.TfaUrl.V) > 0 && len(user.TfaSecret.V) > 0, nil
 }
 
 func (m tfaManager) MustGetActive() bool {
@@ -113,32 +153,29 @@ func (m tfaManager) MustGetActive() bool {
 }
 
 func (m tfaManager) Verify(otp string) (string, error) {
-	token := m.cookie.Get(TfaCookieKey)
-	if len(token) == 0 {
-		return "", ErrorMissingTfaCookie
-	}
-	var u User
-	if err := m.cache.Get(createTfaCacheKey(token), &u); err != nil {
+	token, u, err := m.getPending()
+	if err != nil {
 		return "", err
 	}
-	if u.Id == 0 {
-		return "", ErrorInvalidUser
+	throttle := m.manager.throttle()
+	subjects := []throttleSubject{throttle.tfa(u.Id), throttle.ip(getRequestIp(m.manager.req, m.manager.config.TrustedProxies))}
+	if err := throttle.check(subjects...); err != nil {
+		return "", err
 	}
-	err := quirk.New(m.db).
-		Q(fmt.Sprintf(`SELECT id, email, roles, tfa_secret FROM %s`, usersTable)).
-		Q("WHERE id = @id", quirk.Map{"id": u.Id}).
-		Exec(&u)
+	u, err = m.manager.userStore().Get(u.Id, "")
 	if err != nil {
 		return "", err
 	}
 	if valid := totp.Validate(otp, u.TfaSecret.V); !valid {
+		if err := throttle.fail(subjects...); err != nil {
+			return "", err
+		}
 		return "", ErrorInvalidOtp
 	}
-	if err := m.cache.Set(token, "", time.Millisecond); err != nil {
+	if err := throttle.reset(throttle.tfa(u.Id)); err != nil {
 		return "", err
 	}
-	m.cookie.Set(TfaCookieKey, "", time.Millisecond)
-	return m.manager.Session().New(u)
+	return m.complete(token, u)
 }
 
 func (m tfaManager) MustVerify(otp string) string {
@@ -149,12 +186,71 @@ func (m tfaManager) MustVerify(otp string) string {
 	return token
 }
 
+func (m tfaManager) VerifyCode(code string) (string, error) {
+	token, u, err := m.getPending()
+	if err != nil {
+		return "", err
+	}
+	throttle := m.manager.throttle()
+	subjects := []throttleSubject{throttle.tfa(u.Id), throttle.ip(getRequestIp(m.manager.req, m.manager.config.TrustedProxies))}
+	if err := throttle.check(subjects...); err != nil {
+		return "", err
+	}
+	u, err = m.manager.CustomUser(u.Id, "").Get()
+	if err != nil {
+		return "", err
+	}
+	ok, err := m.consumeCode(u, code)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		if err := throttle.fail(subjects...); err != nil {
+			return "", err
+		}
+		return "", ErrorInvalidOtp
+	}
+	if err := throttle.reset(throttle.tfa(u.Id)); err != nil {
+		return "", err
+	}
+	return m.complete(token, u)
+}
+
+func (m tfaManager) MustVerifyCode(code string) string {
+	token, err := m.VerifyCode(code)
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
 func (m tfaManager) VerifyCodes(email, codes string) (bool, error) {
+	throttle := m.manager.throttle()
+	subjects := []throttleSubject{throttle.email(email), throttle.ip(getRequestIp(m.manager.req, m.manager.config.TrustedProxies))}
+	if err := throttle.check(subjects...); err != nil {
+		return false, err
+	}
 	u, err := m.manager.CustomUser(0, email).Get()
 	if err != nil {
 		return false, err
 	}
-	return u.TfaCodes.V == codes, nil
+	if u.Id > 0 {
+		subjects = append(subjects, throttle.tfa(u.Id))
+		if err := throttle.check(subjects...); err != nil {
+			return false, err
+		}
+	}
+	if u.Id == 0 || len(codes) == 0 {
+		return false, throttle.fail(subjects...)
+	}
+	ok, err := m.consumeCode(u, codes)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, throttle.fail(subjects...)
+	}
+	return true, throttle.reset(throttle.email(email), throttle.tfa(u.Id))
 }
 
 func (m tfaManager) MustVerifyCodes(email, codes string) bool {
@@ -165,15 +261,187 @@ func (m tfaManager) MustVerifyCodes(email, codes string) bool {
 	return verified
 }
 
+func (m tfaManager) GenerateCodes(id ...int) ([]string, error) {
+	userId, err := m.getUserId(id...)
+	if err != nil {
+		return nil, err
+	}
+	codes, hashes := m.createCodes()
+	_, err = m.manager.userStore().Update(
+		userId, "", map[string]any{UserTfaCodes: hashes}, map[string]any{UserTfa: true},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (m tfaManager) MustGenerateCodes(id ...int) []string {
+	codes, err := m.GenerateCodes(id...)
+	if err != nil {
+		panic(err)
+	}
+	return codes
+}
+
+func (m tfaManager) RemainingCodes(id ...int) (int, error) {
+	userId, err := m.getUserId(id...)
+	if err != nil {
+		return 0, err
+	}
+	u, err := m.manager.CustomUser(userId, "").Get()
+	if err != nil {
+		return 0, err
+	}
+	if len(u.TfaCodes.V) == 0 {
+		return 0, nil
+	}
+	return len(strings.Split(u.TfaCodes.V, tfaCodesSeparator)), nil
+}
+
+func (m tfaManager) MustRemainingCodes(id ...int) int {
+	n, err := m.RemainingCodes(id...)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func (m tfaManager) SendOtp() error {
+	token, u, err := m.getPending()
+	if err != nil {
+		return err
+	}
+	throttle := m.manager.throttle()
+	subjects := []throttleSubject{throttle.otp(u.Id), throttle.tfa(u.Id), throttle.ip(getRequestIp(m.manager.req, m.manager.config.TrustedProxies))}
+	if err := throttle.check(subjects...); err != nil {
+		return err
+	}
+	if err := throttle.fail(throttle.otp(u.Id)); err != nil {
+		return err
+	}
+	u, err = m.manager.CustomUser(u.Id, "").Get()
+	if err != nil {
+		return err
+	}
+	if u.Id == 0 {
+		return ErrorInvalidUser
+	}
+	code, err := createTfaOtp()
+	if err != nil {
+		return err
+	}
+	em := createEmailManager(m.manager).(*emailManager)
+	if err := m.cache.Set(createTfaOtpCacheKey(token), hashTfaOtp(u.Id, code), em.config.Otp.Expiration); err != nil {
+		return err
+	}
+	return em.sendCode(u, code)
+}
+
+func (m tfaManager) MustSendOtp() {
+	if err := m.SendOtp(); err != nil {
+		panic(err)
+	}
+}
+
+func (m tfaManager) VerifyOtp(otp string) (string, error) {
+	token, u, err := m.getPending()
+	if err != nil {
+		return "", err
+	}
+	throttle := m.manager.throttle()
+	subjects := []throttleSubject{throttle.tfa(u.Id), throttle.ip(getRequestIp(m.manager.req, m.manager.config.TrustedProxies))}
+	if err := throttle.check(subjects...); err != nil {
+		return "", err
+	}
+	var hash string
+	if err := m.cache.Get(createTfaOtpCacheKey(token), &hash); err != nil {
+		return "", err
+	}
+	if len(hash) == 0 || !hmac.Equal([]byte(hash), []byte(hashTfaOtp(u.Id, otp))) {
+		if err := throttle.fail(subjects...); err != nil {
+			return "", err
+		}
+		return "", ErrorInvalidOtp
+	}
+	if err := m.cache.Destroy(createTfaOtpCacheKey(token)); err != nil {
+		return "", err
+	}
+	if err := throttle.reset(throttle.tfa(u.Id), throttle.otp(u.Id)); err != nil {
+		return "", err
+	}
+	u, err = m.manager.CustomUser(u.Id, "").Get()
+	if err != nil {
+		return "", err
+	}
+	return m.complete(token, u)
+}
+
+func (m tfaManager) MustVerifyOtp(otp string) string {
+	token, err := m.VerifyOtp(otp)
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
+func (m tfaManager) TrustDevice() error {
+	secret := m.manager.config.Tfa.Secret
+	if len(secret) == 0 {
+		return ErrorMissingSecret
+	}
+	session, err := m.manager.Session().Get()
+	if err != nil {
+		return err
+	}
+	if session.Id == 0 {
+		return ErrorInvalidUser
+	}
+	duration := m.manager.config.Tfa.TrustDuration
+	if duration == 0 {
+		duration = DefaultTrustDuration
+	}
+	nonce := uniuri.NewLen(TokenLength)
+	if err := m.cache.Set(createTfaTrustCacheKey(session.Id, nonce), session.Id, duration); err != nil {
+		return err
+	}
+	m.cookie.Set(TfaTrustCookieKey, strconv.Itoa(session.Id)+tfaTrustSeparator+nonce, duration, cookie.Sign(secret))
+	return nil
+}
+
+func (m tfaManager) MustTrustDevice() {
+	if err := m.TrustDevice(); err != nil {
+		panic(err)
+	}
+}
+
+func (m tfaManager) ForgetDevice() error {
+	userId, nonce, ok := m.manager.getTrustedDevice()
+	m.cookie.Destroy(TfaTrustCookieKey)
+	if !ok {
+		return nil
+	}
+	return m.cache.Destroy(createTfaTrustCacheKey(userId, nonce))
+}
+
+func (m tfaManager) MustForgetDevice() {
+	if err := m.ForgetDevice(); err != nil {
+		panic(err)
+	}
+}
+
 func (m tfaManager) Enable(id ...int) error {
 	userId, err := m.getUserId(id...)
 	if err != nil {
 		return err
 	}
-	u, err := m.manager.User().Get()
+	u, err := m.manager.CustomUser(userId, "").Get()
 	if err != nil {
 		return err
 	}
+	if u.Id == 0 {
+		return ErrorInvalidUser
+	}
 	key, err := totp.Generate(
 		totp.GenerateOpts{
 			Issuer:      m.getHost(),
@@ -183,19 +451,22 @@ func (m tfaManager) Enable(id ...int) error {
 	if err != nil {
 		return err
 	}
-	codes := uniuri.NewLen(tfaSecretCodesLength)
-	return quirk.New(m.db).
-		Q(fmt.Sprintf(`UPDATE %s`, usersTable)).
-		Q(
-			"SET tfa = @tfa, tfa_codes = @tfa-codes, tfa_secret = @tfa-secret, tfa_url = @tfa-url", quirk.Map{
-				"tfa":        true,
-				"tfa-codes":  codes,
-				"tfa-secret": key.Secret(),
-				"tfa-url":    key.String(),
-			},
-		).
-		Q("WHERE id = @id", quirk.Map{"id": userId}).
-		Exec()
+	_, err = m.manager.userStore().Update(
+		userId, "", map[string]any{
+			UserTfa:       true,
+			UserTfaCodes:  sql.Null[string]{},
+			UserTfaSecret: key.Secret(),
+			UserTfaUrl:    key.String(),
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if err := m.manager.forgetDevices(userId); err != nil {
+		return err
+	}
+	m.manager.emit(Event{Name: EventTfaEnable, UserId: userId, Email: u.Email})
+	return nil
 }
 
 func (m tfaManager) MustEnable(id ...int) {
@@ -210,15 +481,23 @@ func (m tfaManager) Disable(id ...int) error {
 	if err != nil {
 		return err
 	}
-	err = quirk.New(m.db).
-		Q(fmt.Sprintf(`UPDATE %s`, usersTable)).
-		Q("SET tfa = false, tfa_codes = NULL, tfa_secret = NULL, tfa_url = NULL").
-		Q("WHERE id = @id", quirk.Map{"id": userId}).
-		Exec()
+	_, err = m.manager.userStore().Update(
+		userId, "", map[string]any{
+			UserTfa:       false,
+			UserTfaCodes:  sql.Null[string]{},
+			UserTfaSecret: sql.Null[string]{},
+			UserTfaUrl:    sql.Null[string]{},
+		},
+	)
 	if err != nil {
 		return err
 	}
 	m.cookie.Destroy(TfaCookieKey)
+	m.cookie.Destroy(TfaTrustCookieKey)
+	if err := m.manager.forgetDevices(userId); err != nil {
+		return err
+	}
+	m.manager.emit(Event{Name: EventTfaDisable, UserId: userId})
 	return nil
 }
 
@@ -234,11 +513,7 @@ func (m tfaManager) CreateQrImageBase64(id ...int) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	var u User
-	err = quirk.New(m.db).
-		Q(fmt.Sprintf(`SELECT tfa_url FROM %s`, usersTable)).
-		Q("WHERE id = @id", quirk.Map{"id": userId}).
-		Exec(&u)
+	u, err := m.manager.userStore().Get(userId, "")
 	if err != nil {
 		return "", err
 	}
@@ -288,3 +563,106 @@ func (m tfaManager) getUserId(id ...int) (int, error) {
 	}
 	return userId, nil
 }
+
+func (m tfaManager) getPending() (string, User, error) {
+	var u User
+	token := m.cookie.Get(TfaCookieKey)
+	if len(token) == 0 {
+		return "", u, ErrorMissingTfaCookie
+	}
+	if err := m.cache.Get(createTfaCacheKey(token), &u); err != nil {
+		return "", u, err
+	}
+	if u.Id == 0 {
+		return "", u, ErrorInvalidUser
+	}
+	return token, u, nil
+}
+
+func (m tfaManager) complete(token string, u User) (string, error) {
+	if err := m.cache.DestroyMany(createTfaCacheKey(token), createTfaOtpCacheKey(token)); err != nil {
+		return "", err
+	}
+	m.cookie.Set(TfaCookieKey, "", time.Millisecond)
+	return m.manager.createSession(u)
+}
+
+func (m tfaManager) consumeCode(u User, code string) (bool, error) {
+	if len(u.TfaCodes.V) == 0 {
+		return false, nil
+	}
+	hashes := strings.Split(u.TfaCodes.V, tfaCodesSeparator)
+	index := slices.IndexFunc(
+		hashes, func(hash string) bool {
+			salt, _, ok := strings.Cut(hash, tfaTrustSeparator)
+			return ok && hmac.Equal([]byte(hash), []byte(hashTfaCode(salt, code)))
+		},
+	)
+	if index < 0 {
+		return false, nil
+	}
+	return m.manager.userStore().Update(
+		u.Id, "",
+		map[string]any{UserTfaCodes: strings.Join(slices.Delete(slices.Clone(hashes), index, index+1), tfaCodesSeparator)},
+		map[string]any{UserTfaCodes: u.TfaCodes.V},
+	)
+}
+
+func (m tfaManager) createCodes() ([]string, string) {
+	n := m.manager.config.Tfa.Codes
+	if n <= 0 {
+		n = DefaultTfaCodes
+	}
+	codes := make([]string, n)
+	hashes := make([]string, n)
+	for i := range codes {
+		code := uniuri.NewLenChars(tfaCodeLength, []byte(tfaCodeChars))
+		codes[i] = code[:tfaCodeLength/2] + "-" + code[tfaCodeLength/2:]
+		hashes[i] = hashTfaCode(uniuri.NewLen(tfaCodeSaltLength), code)
+	}
+	return codes, strings.Join(hashes, tfaCodesSeparator)
+}
+
+func (m *manager) trustedDevice(userId int) bool {
+	id, nonce, ok := m.getTrustedDevice()
+	return ok && id == userId && m.cache.Exists(createTfaTrustCacheKey(id, nonce))
+}
+
+func (m *manager) getTrustedDevice() (int, string, bool) {
+	secret := m.config.Tfa.Secret
+	if len(secret) == 0 {
+		return 0, "", false
+	}
+	id, nonce, ok := strings.Cut(m.cookie.Get(TfaTrustCookieKey, cookie.Sign(secret)), tfaTrustSeparator)
+	if !ok || len(nonce) == 0 {
+		return 0, "", false
+	}
+	userId, err := strconv.Atoi(id)
+	return userId, nonce, err == nil && userId > 0
+}
+
+func (m *manager) forgetDevices(userId int) error {
+	return m.cache.DestroyPrefix(createTfaTrustCacheKey(userId, ""))
+}
+
+func createTfaOtp() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", tfaOtpLength, n.Int64()), nil
+}
+
+func hashTfaOtp(userId int, code string) string {
+	hash := sha256.Sum256([]byte(strconv.Itoa(userId) + tfaTrustSeparator + normalizeTfaCode(code)))
+	return hex.EncodeToString(hash[:])
+}
+
+func hashTfaCode(salt, code string) string {
+	hash := sha256.Sum256([]byte(salt + tfaTrustSeparator + normalizeTfaCode(code)))
+	return salt + tfaTrustSeparator + hex.EncodeToString(hash[:])
+}
+
+func normalizeTfaCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -2,9 +2,11 @@ package auth
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 	
@@ -13,6 +15,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	
 	"github.com/daarlabs/arcanum/cache"
+	"github.com/daarlabs/arcanum/cache/memory"
 	"github.com/daarlabs/arcanum/cookie"
 	
 	"github.com/daarlabs/arcanum/quirk"
@@ -22,8 +25,8 @@ func TestTfa(t *testing.T) {
 	db, redis := createTestDatabaseConnection(t), createTestRedisConnection(t)
 	assert.NotNil(t, db)
 	assert.NotNil(t, redis)
-	assert.NoError(t, DropTable(quirk.New(db)))
-	assert.NoError(t, CreateTable(quirk.New(db)))
+	assert.NoError(t, DropTable(db))
+	assert.NoError(t, CreateTable(db))
 	um := CreateUserManager(db, nil, 0, "")
 	_, err := um.Create(
 		User{
@@ -39,7 +42,7 @@ func TestTfa(t *testing.T) {
 	assert.True(t, r.Id > 0)
 	t.Cleanup(
 		func() {
-			assert.NoError(t, DropTable(quirk.New(db)))
+			assert.NoError(t, DropTable(db))
 		},
 	)
 	t.Run(
@@ -140,3 +143,163 @@ func TestTfa(t *testing.T) {
 		},
 	)
 }
+
+func TestTfaFactors(t *testing.T) {
+	c := cache.New(context.Background(), memory.New(t.TempDir()), nil)
+	createTestManager := func(config Config, cookies ...*http.Cookie) (*manager, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		for _, item := range cookies {
+			req.AddCookie(item)
+		}
+		res := httptest.NewRecorder()
+		return &manager{req: req, res: res, cookie: cookie.New(req, res, "/"), cache: c, config: config}, res
+	}
+	t.Run(
+		"codes", func(t *testing.T) {
+			m, _ := createTestManager(Config{Tfa: Tfa{Codes: 20}})
+			codes, hashes := createTfaManager(m).(*tfaManager).createCodes()
+			assert.Len(t, codes, 20)
+			assert.Len(t, strings.Split(hashes, tfaCodesSeparator), 20)
+			assert.Len(t, codes[0], tfaCodeLength+1)
+			assert.NotContains(t, hashes, normalizeTfaCode(codes[0]))
+			_, other := createTfaManager(m).(*tfaManager).createCodes()
+			assert.NotEqual(t, hashes, other)
+			store := &testUserStore{
+				users: map[int]User{1: {Id: 1, TfaCodes: sql.Null[string]{V: hashes, Valid: true}}},
+			}
+			m, _ = createTestManager(Config{UserStore: store})
+			tm := createTfaManager(m).(*tfaManager)
+			ok, err := tm.consumeCode(store.users[1], strings.ToUpper(strings.ReplaceAll(codes[0], "-", "")))
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.Len(t, strings.Split(store.users[1].TfaCodes.V, tfaCodesSeparator), 19)
+			ok, err = tm.consumeCode(store.users[1], codes[0])
+			assert.NoError(t, err)
+			assert.False(t, ok)
+		},
+	)
+	t.Run(
+		"enable", func(t *testing.T) {
+			store := &testUserStore{
+				users: map[int]User{
+					1: {Id: 1, Email: "admin@linduska.dev"},
+					2: {Id: 2, Email: "dominik@linduska.dev", TfaCodes: sql.Null[string]{V: "stale", Valid: true}},
+				},
+			}
+			events := make([]Event, 0)
+			config := Config{
+				UserStore: store,
+				OnEvent: func(event Event) {
+					events = append(events, event)
+				},
+			}
+			m, _ := createTestManager(config)
+			token := m.Session().MustNew(store.users[1])
+			m, _ = createTestManager(config, &http.Cookie{Name: SessionCookieKey, Value: token})
+			tm := createTfaManager(m)
+			tm.MustEnable(2)
+			assert.True(t, store.users[2].Tfa)
+			assert.False(t, store.users[1].Tfa)
+			assert.Equal(t, "dominik@linduska.dev", events[len(events)-1].Email)
+			assert.Equal(t, 0, tm.MustRemainingCodes(2))
+			codes := tm.MustGenerateCodes(2)
+			assert.Len(t, codes, DefaultTfaCodes)
+			assert.Equal(t, DefaultTfaCodes, tm.MustRemainingCodes(2))
+		},
+	)
+	t.Run(
+		"send otp throttle", func(t *testing.T) {
+			store := &testUserStore{users: map[int]User{1: {Id: 1, Email: "dominik@linduska.dev", Tfa: true}}}
+			sent := 0
+			config := Config{
+				UserStore: store,
+				Email: Email{
+					Send: func(m EmailMessage) error {
+						sent++
+						return nil
+					},
+				},
+			}
+			assert.NoError(t, c.Set(createTfaCacheKey("pending"), User{Id: 1}, time.Minute))
+			m, _ := createTestManager(config, &http.Cookie{Name: TfaCookieKey, Value: "pending"})
+			assert.NoError(t, createTfaManager(m).SendOtp())
+			assert.ErrorIs(t, createTfaManager(m).SendOtp(), ErrorTooManyAttempts)
+			assert.Equal(t, 1, sent)
+		},
+	)
+	t.Run(
+		"otp", func(t *testing.T) {
+			code, err := createTfaOtp()
+			assert.NoError(t, err)
+			assert.Len(t, code, tfaOtpLength)
+			var message EmailMessage
+			m, _ := createTestManager(
+				Config{
+					Email: Email{
+						Send: func(m EmailMessage) error {
+							message = m
+							return nil
+						},
+					},
+				},
+			)
+			assert.NoError(t, createEmailManager(m).(*emailManager).sendCode(User{Email: "dominik@linduska.dev"}, code))
+			assert.Equal(t, EmailFlowOtp, message.Flow)
+			assert.Equal(t, code, message.Code)
+			assert.Contains(t, message.Translate("auth.email.otp.text"), code)
+		},
+	)
+	t.Run(
+		"trust device", func(t *testing.T) {
+			config := Config{Tfa: Tfa{Secret: "secret"}}
+			m, _ := createTestManager(config)
+			token := m.Session().MustNew(User{Id: 1, Email: "dominik@linduska.dev"})
+			m, res := createTestManager(config, &http.Cookie{Name: SessionCookieKey, Value: token})
+			createTfaManager(m).MustTrustDevice()
+			var trust *http.Cookie
+			for _, item := range res.Result().Cookies() {
+				if item.Name == TfaTrustCookieKey {
+					trust = item
+				}
+			}
+			assert.NotNil(t, trust)
+			m, _ = createTestManager(config, trust)
+			assert.True(t, m.trustedDevice(1))
+			assert.False(t, m.trustedDevice(2))
+			m, _ = createTestManager(Config{Tfa: Tfa{Secret: "other"}}, trust)
+			assert.False(t, m.trustedDevice(1))
+			m, _ = createTestManager(Config{}, &http.Cookie{Name: SessionCookieKey, Value: token})
+			assert.ErrorIs(t, createTfaManager(m).TrustDevice(), ErrorMissingSecret)
+			m, _ = createTestManager(config, trust)
+			createTfaManager(m).MustForgetDevice()
+			m, _ = createTestManager(config, trust)
+			assert.False(t, m.trustedDevice(1))
+		},
+	)
+	t.Run(
+		"forget devices", func(t *testing.T) {
+			store := &testUserStore{users: map[int]User{1: {Id: 1, Email: "dominik@linduska.dev", Tfa: true}}}
+			config := Config{Tfa: Tfa{Secret: "secret"}, UserStore: store, Password: Password{TimeCost: 1, MemoryCost: 1024}}
+			m, _ := createTestManager(config)
+			token := m.Session().MustNew(User{Id: 1, Email: "dominik@linduska.dev"})
+			trust := func() *http.Cookie {
+				m, res := createTestManager(config, &http.Cookie{Name: SessionCookieKey, Value: token})
+				createTfaManager(m).MustTrustDevice()
+				for _, item := range res.Result().Cookies() {
+					if item.Name == TfaTrustCookieKey {
+						return item
+					}
+				}
+				return nil
+			}
+			device := trust()
+			m, _ = createTestManager(config, device)
+			m.CustomUser(1, "").MustForceUpdatePassword("123456789")
+			assert.False(t, m.trustedDevice(1))
+			device = trust()
+			m, _ = createTestManager(config, device)
+			createTfaManager(m).MustDisable(1)
+			assert.False(t, m.trustedDevice(1))
+		},
+	)
+}
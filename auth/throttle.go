@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	
+	"github.com/daarlabs/arcanum/cache"
+)
+
+type Throttle struct {
+	Disabled      bool                `json:"disabled" yaml:"disabled" toml:"disabled"`
+	MaxAttempts   int                 `json:"maxAttempts" yaml:"maxAttempts" toml:"maxAttempts"`
+	MaxIpAttempts int                 `json:"maxIpAttempts" yaml:"maxIpAttempts" toml:"maxIpAttempts"`
+	Window        time.Duration       `json:"window" yaml:"window" toml:"window"`
+	Lockout       time.Duration       `json:"lockout" yaml:"lockout" toml:"lockout"`
+	Backoff       time.Duration       `json:"backoff" yaml:"backoff" toml:"backoff"`
+	MaxBackoff    time.Duration       `json:"maxBackoff" yaml:"maxBackoff" toml:"maxBackoff"`
+	OnLockout     func(event Lockout) `json:"-" yaml:"-" toml:"-"`
+}
+
+type Lockout struct {
+	Subject  string
+	Value    string
+	Attempts int64
+	Until    time.Time
+}
+
+type throttle struct {
+	cache  cache.Client
+	config Throttle
+}
+
+type throttleSubject struct {
+	name  string
+	value string
+	max   int
+}
+
+const (
+	ThrottleSubjectEmail = "email"
+	ThrottleSubjectIp    = "ip"
+	ThrottleSubjectTfa   = "tfa"
+	ThrottleSubjectOtp   = "otp"
+)
+
+const (
+	DefaultMaxAttempts     = 5
+	DefaultMaxIpAttempts   = 50
+	DefaultThrottleWindow  = 15 * time.Minute
+	DefaultLockoutDuration = 15 * time.Minute
+	DefaultBackoff         = time.Second
+	DefaultMaxBackoff      = time.Minute
+)
+
+func createThrottle(cache cache.Client, config Throttle) throttle {
+	if config.MaxAttempts == 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+	if config.MaxIpAttempts == 0 {
+		config.MaxIpAttempts = DefaultMaxIpAttempts
+	}
+	if config.Window == 0 {
+		config.Window = DefaultThrottleWindow
+	}
+	if config.Lockout == 0 {
+		config.Lockout = DefaultLockoutDuration
+	}
+	if config.Backoff == 0 {
+		config.Backoff = DefaultBackoff
+	}
+	if config.MaxBackoff == 0 {
+		config.MaxBackoff = DefaultMaxBackoff
+	}
+	return throttle{
+		cache:  cache,
+		config: config,
+	}
+}
+
+func (t throttle) email(email string) throttleSubject {
+	return throttleSubject{name: ThrottleSubjectEmail, value: strings.ToLower(strings.TrimSpace(email)), max: t.config.MaxAttempts}
+}
+
+func (t throttle) ip(ip string) throttleSubject {
+	return throttleSubject{name: ThrottleSubjectIp, value: ip, max: t.config.MaxIpAttempts}
+}
+
+func (t throttle) tfa(id int) throttleSubject {
+	return throttleSubject{name: ThrottleSubjectTfa, value: fmt.Sprint(id), max: t.config.MaxAttempts}
+}
+
+func (t throttle) otp(id int) throttleSubject {
+	return throttleSubject{name: ThrottleSubjectOtp, value: fmt.Sprint(id), max: t.config.MaxAttempts}
+}
+
+func (t throttle) check(subjects ...throttleSubject) error {
+	if t.config.Disabled || t.cache == nil {
+		return nil
+	}
+	for _, s := range subjects {
+		if t.cache.Exists(createThrottleBlockCacheKey(s.name, s.value)) {
+			return ErrorTooManyAttempts
+		}
+	}
+	return nil
+}
+
+func (t throttle) fail(subjects ...throttleSubject) error {
+	if t.config.Disabled || t.cache == nil {
+		return nil
+	}
+	for _, s := range subjects {
+		attempts, err := t.cache.Increment(createThrottleAttemptCacheKey(s.name, s.value), 1, t.config.Window)
+		if err != nil {
+			return err
+		}
+		block := t.config.Backoff << min(attempts-1, 32)
+		if block <= 0 || block > t.config.MaxBackoff {
+			block = t.config.MaxBackoff
+		}
+		locked := attempts >= int64(s.max)
+		if locked {
+			block = t.config.Lockout
+		}
+		if err := t.cache.Set(createThrottleBlockCacheKey(s.name, s.value), attempts, block); err != nil {
+			return err
+		}
+		if locked && t.config.OnLockout != nil {
+			t.config.OnLockout(
+				Lockout{
+					Subject:  s.name,
+					Value:    s.value,
+					Attempts: attempts,
+					Until:    time.Now().Add(block),
+				},
+			)
+		}
+	}
+	return nil
+}
+
+func (t throttle) reset(subjects ...throttleSubject) error {
+	if t.config.Disabled || t.cache == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(subjects)*2)
+	for _, s := range subjects {
+		keys = append(keys, createThrottleAttemptCacheKey(s.name, s.value), createThrottleBlockCacheKey(s.name, s.value))
+	}
+	return t.cache.DestroyMany(keys...)
+}
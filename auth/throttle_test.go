@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	
+	"github.com/stretchr/testify/assert"
+	
+	"github.com/daarlabs/arcanum/cache"
+	"github.com/daarlabs/arcanum/cache/memory"
+)
+
+func TestThrottle(t *testing.T) {
+	createTestThrottle := func(config Throttle) throttle {
+		return createThrottle(cache.New(context.Background(), memory.New(t.TempDir()), nil), config)
+	}
+	t.Run(
+		"backoff", func(t *testing.T) {
+			th := createTestThrottle(Throttle{Backoff: 50 * time.Millisecond, MaxBackoff: 80 * time.Millisecond})
+			email := th.email("Test@Example.com")
+			assert.NoError(t, th.check(email))
+			assert.NoError(t, th.fail(email))
+			assert.ErrorIs(t, th.check(th.email("test@example.com")), ErrorTooManyAttempts)
+			time.Sleep(60 * time.Millisecond)
+			assert.NoError(t, th.check(email))
+			assert.NoError(t, th.fail(email))
+			time.Sleep(60 * time.Millisecond)
+			assert.ErrorIs(t, th.check(email), ErrorTooManyAttempts)
+			time.Sleep(30 * time.Millisecond)
+			assert.NoError(t, th.check(email))
+		},
+	)
+	t.Run(
+		"lockout", func(t *testing.T) {
+			var lockouts []Lockout
+			th := createTestThrottle(
+				Throttle{
+					MaxAttempts: 3,
+					Backoff:     time.Millisecond,
+					Lockout:     time.Hour,
+					OnLockout: func(event Lockout) {
+						lockouts = append(lockouts, event)
+					},
+				},
+			)
+			email, ip := th.email("test@example.com"), th.ip("127.0.0.1")
+			for i := 0; i < 3; i++ {
+				time.Sleep(5 * time.Millisecond)
+				assert.NoError(t, th.check(email, ip))
+				assert.NoError(t, th.fail(email, ip))
+			}
+			time.Sleep(5 * time.Millisecond)
+			assert.ErrorIs(t, th.check(email), ErrorTooManyAttempts)
+			assert.NoError(t, th.check(ip))
+			assert.Len(t, lockouts, 1)
+			assert.Equal(t, ThrottleSubjectEmail, lockouts[0].Subject)
+			assert.Equal(t, int64(3), lockouts[0].Attempts)
+			assert.NoError(t, th.reset(email))
+			assert.NoError(t, th.check(email))
+		},
+	)
+	t.Run(
+		"disabled", func(t *testing.T) {
+			th := createTestThrottle(Throttle{Disabled: true})
+			assert.NoError(t, th.fail(th.tfa(1)))
+			assert.NoError(t, th.check(th.tfa(1)))
+		},
+	)
+	t.Run(
+		"request ip", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.RemoteAddr = "203.0.113.7:4321"
+			req.Header.Set("X-Forwarded-For", "1.1.1.1")
+			assert.Equal(t, "203.0.113.7", getRequestIp(req, nil))
+			req.RemoteAddr = "10.0.0.2:4321"
+			req.Header.Set("X-Forwarded-For", "1.1.1.1, 198.51.100.9, 10.0.0.1")
+			assert.Equal(t, "10.0.0.2", getRequestIp(req, nil))
+			assert.Equal(t, "198.51.100.9", getRequestIp(req, []string{"10.0.0.0/8"}))
+			assert.Equal(t, "10.0.0.1", getRequestIp(req, []string{"10.0.0.2"}))
+		},
+	)
+}
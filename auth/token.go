@@ -0,0 +1,256 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	
+	"github.com/dchest/uniuri"
+	
+	"github.com/daarlabs/arcanum/quirk"
+)
+
+type TokenManager interface {
+	Create(userId int, name string, scopes []string, expiration time.Duration) (string, error)
+	List(userId int) ([]Token, error)
+	Verify(token string) (Session, error)
+	Revoke(id int) error
+	RevokeAll(userId int) error
+	
+	MustCreate(userId int, name string, scopes []string, expiration time.Duration) string
+	MustList(userId int) []Token
+	MustVerify(token string) Session
+	MustRevoke(id int)
+	MustRevokeAll(userId int)
+}
+
+type Token struct {
+	Id         int                 `json:"id"`
+	UserId     int                 `json:"userId"`
+	Name       string              `json:"name"`
+	Scopes     []string            `json:"scopes"`
+	ExpiresAt  sql.Null[time.Time] `json:"expiresAt"`
+	LastUsedAt sql.Null[time.Time] `json:"lastUsedAt"`
+	RevokedAt  sql.Null[time.Time] `json:"revokedAt"`
+	CreatedAt  time.Time           `json:"createdAt"`
+}
+
+type tokenManager struct {
+	db     *quirk.DB
+	config Config
+}
+
+type tokenOwner struct {
+	Id        int      `db:"id"`
+	UserId    int      `db:"user_id"`
+	Scopes    []string `db:"scopes"`
+	Email     string   `db:"email"`
+	Roles     []string `db:"roles"`
+	FirstName string   `db:"first_name"`
+	LastName  string   `db:"last_name"`
+	Locale    string   `db:"locale"`
+	TenantId  int      `db:"tenant_id"`
+}
+
+const (
+	TokenUserId     = "user_id"
+	TokenName       = "name"
+	TokenHash       = "hash"
+	TokenScopes     = "scopes"
+	TokenExpiresAt  = "expires_at"
+	TokenLastUsedAt = "last_used_at"
+	TokenRevokedAt  = "revoked_at"
+)
+
+const (
+	TokenPrefix = "arc_"
+	TokenLength = 40
+	ScopeAll    = PermissionAll
+)
+
+const (
+	tokensTable   = "user_tokens"
+	bearerPrefix  = "Bearer "
+	authorization = "Authorization"
+)
+
+func createTokenManager(db *quirk.DB, config Config) TokenManager {
+	return &tokenManager{
+		db:     db,
+		config: config,
+	}
+}
+
+func (t *tokenManager) Create(userId int, name string, scopes []string, expiration time.Duration) (string, error) {
+	if userId == 0 {
+		return "", ErrorInvalidUser
+	}
+	if scopes == nil {
+		scopes = []string{ScopeAll}
+	}
+	token := TokenPrefix + uniuri.NewLen(TokenLength)
+	expiresAt := sql.Null[time.Time]{V: time.Now().Add(expiration), Valid: expiration > 0}
+	err := quirk.New(t.db).Q(fmt.Sprintf(`INSERT INTO %s`, tokensTable)).
+		Q(
+			fmt.Sprintf(`(%s, %s, %s, %s, %s)`, TokenUserId, TokenName, TokenHash, TokenScopes, TokenExpiresAt),
+		).
+		Q(
+			`VALUES (@user_id, @name, @hash, @scopes, @expires_at)`,
+			quirk.Map{
+				TokenUserId:    userId,
+				TokenName:      name,
+				TokenHash:      hashToken(token),
+				TokenScopes:    scopes,
+				TokenExpiresAt: expiresAt,
+			},
+		).
+		Exec()
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (t *tokenManager) MustCreate(userId int, name string, scopes []string, expiration time.Duration) string {
+	token, err := t.Create(userId, name, scopes, expiration)
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
+func (t *tokenManager) List(userId int) ([]Token, error) {
+	r := make([]Token, 0)
+	err := quirk.New(t.db).
+		Q(
+			fmt.Sprintf(
+				`SELECT id, %s, %s, %s, %s, %s, %s, %s FROM %s`,
+				TokenUserId, TokenName, TokenScopes, TokenExpiresAt, TokenLastUsedAt, TokenRevokedAt, quirk.CreatedAt,
+				tokensTable,
+			),
+		).
+		Q(`WHERE user_id = @user_id`, quirk.Map{TokenUserId: userId}).
+		Q(`ORDER BY id DESC`).
+		Exec(&r)
+	return r, err
+}
+
+func (t *tokenManager) MustList(userId int) []Token {
+	r, err := t.List(userId)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (t *tokenManager) Verify(token string) (Session, error) {
+	if !strings.HasPrefix(token, TokenPrefix) {
+		return Session{}, ErrorInvalidToken
+	}
+	var r tokenOwner
+	schema := t.config.UserSchema
+	err := quirk.New(t.db).
+		Q(
+			fmt.Sprintf(
+				`SELECT t.id, t.user_id, t.scopes, %s FROM %s t`,
+				schema.selectColumns("u", UserEmail, UserRoles, UserFirstName, UserLastName, UserLocale, UserTenantId),
+				tokensTable,
+			),
+		).
+		Q(fmt.Sprintf(`INNER JOIN %s u ON u.%s = t.user_id`, schema.table(), schema.column(quirk.Id))).
+		Q(`WHERE t.hash = @hash`, quirk.Map{TokenHash: hashToken(token)}).
+		Q(`AND t.revoked_at IS NULL`).
+		Q(`AND (t.expires_at IS NULL OR t.expires_at > CURRENT_TIMESTAMP)`).
+		Q(fmt.Sprintf(`AND u.%s = true`, schema.column(UserActive))).
+		Q(`LIMIT 1`).
+		Exec(&r)
+	if err != nil {
+		return Session{}, err
+	}
+	if r.Id == 0 {
+		return Session{}, ErrorInvalidToken
+	}
+	err = quirk.New(t.db).
+		Q(fmt.Sprintf(`UPDATE %s SET %s = CURRENT_TIMESTAMP`, tokensTable, TokenLastUsedAt)).
+		Q(`WHERE id = @id`, quirk.Map{quirk.Id: r.Id}).
+		Exec()
+	if err != nil {
+		return Session{}, err
+	}
+	return Session{
+		Id:          r.UserId,
+		Email:       r.Email,
+		FirstName:   r.FirstName,
+		LastName:    r.LastName,
+		Locale:      r.Locale,
+		TenantId:    r.TenantId,
+		Roles:       r.Roles,
+		Scopes:      r.Scopes,
+		Super:       containsSuperRole(t.config.Roles, r.Roles...),
+		Permissions: createPermissions(t.config.Roles, r.Roles...),
+		LastSeenAt:  time.Now(),
+	}, nil
+}
+
+func (t *tokenManager) MustVerify(token string) Session {
+	session, err := t.Verify(token)
+	if err != nil {
+		panic(err)
+	}
+	return session
+}
+
+func (t *tokenManager) Revoke(id int) error {
+	return quirk.New(t.db).
+		Q(fmt.Sprintf(`UPDATE %s SET %s = CURRENT_TIMESTAMP`, tokensTable, TokenRevokedAt)).
+		Q(`WHERE id = @id AND revoked_at IS NULL`, quirk.Map{quirk.Id: id}).
+		Exec()
+}
+
+func (t *tokenManager) MustRevoke(id int) {
+	if err := t.Revoke(id); err != nil {
+		panic(err)
+	}
+}
+
+func (t *tokenManager) RevokeAll(userId int) error {
+	return quirk.New(t.db).
+		Q(fmt.Sprintf(`UPDATE %s SET %s = CURRENT_TIMESTAMP`, tokensTable, TokenRevokedAt)).
+		Q(`WHERE user_id = @user_id AND revoked_at IS NULL`, quirk.Map{TokenUserId: userId}).
+		Exec()
+}
+
+func (t *tokenManager) MustRevokeAll(userId int) {
+	if err := t.RevokeAll(userId); err != nil {
+		panic(err)
+	}
+}
+
+func (s Session) HasScope(scope string) bool {
+	if s.Scopes == nil {
+		return true
+	}
+	return slices.ContainsFunc(
+		s.Scopes, func(item string) bool {
+			return MatchPermission(item, scope)
+		},
+	)
+}
+
+func GetBearerToken(req *http.Request) string {
+	value := req.Header.Get(authorization)
+	if len(value) <= len(bearerPrefix) || !strings.EqualFold(value[:len(bearerPrefix)], bearerPrefix) {
+		return ""
+	}
+	return strings.TrimSpace(value[len(bearerPrefix):])
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	
+	"github.com/stretchr/testify/assert"
+	
+	"github.com/daarlabs/arcanum/cache"
+	"github.com/daarlabs/arcanum/cache/memory"
+	"github.com/daarlabs/arcanum/cookie"
+	"github.com/daarlabs/arcanum/quirk"
+)
+
+func TestToken(t *testing.T) {
+	db := createTestDatabaseConnection(t)
+	assert.NoError(t, DropTable(db))
+	assert.NoError(t, CreateTable(db))
+	t.Cleanup(
+		func() {
+			assert.NoError(t, DropTable(db))
+		},
+	)
+	config := Config{Roles: []Role{{Name: "owner", Super: true}}}
+	userId := CreateUserManager(db, nil, 0, "").MustCreate(
+		User{Active: true, Roles: []string{"owner"}, Email: "dominik@linduska.dev", Password: "123456789"},
+	)
+	tm := createTokenManager(db, config)
+	t.Run(
+		"create verify", func(t *testing.T) {
+			token := tm.MustCreate(userId, "ci", []string{"read"}, time.Hour)
+			session := tm.MustVerify(token)
+			assert.Equal(t, userId, session.Id)
+			assert.Equal(t, []string{"owner"}, session.Roles)
+			assert.True(t, session.Super)
+			assert.True(t, session.HasScope("read"))
+			assert.False(t, session.HasScope("write"))
+			tokens := tm.MustList(userId)
+			assert.Len(t, tokens, 1)
+			assert.True(t, tokens[0].LastUsedAt.Valid)
+		},
+	)
+	t.Run(
+		"bearer session", func(t *testing.T) {
+			token := tm.MustCreate(userId, "api", nil, 0)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			res := httptest.NewRecorder()
+			c := cache.New(context.Background(), memory.New(t.TempDir()), nil)
+			session, err := New(db, req, res, cookie.New(req, res, "/"), c, config).Session().Get()
+			assert.NoError(t, err)
+			assert.Equal(t, 0, session.Id)
+			bearer := config
+			bearer.Bearer = true
+			session, err = New(db, req, res, cookie.New(req, res, "/"), c, bearer).Session().Get()
+			assert.NoError(t, err)
+			assert.Equal(t, userId, session.Id)
+			assert.True(t, session.HasScope("write"))
+		},
+	)
+	t.Run(
+		"revoke expire", func(t *testing.T) {
+			expired := tm.MustCreate(userId, "expired", nil, time.Millisecond)
+			time.Sleep(10 * time.Millisecond)
+			_, err := tm.Verify(expired)
+			assert.ErrorIs(t, err, ErrorInvalidToken)
+			token := tm.MustCreate(userId, "revoked", nil, 0)
+			tokens := tm.MustList(userId)
+			tm.MustRevoke(tokens[0].Id)
+			_, err = tm.Verify(token)
+			assert.ErrorIs(t, err, ErrorInvalidToken)
+			tm.MustRevokeAll(userId)
+			for _, item := range tm.MustList(userId) {
+				assert.True(t, item.RevokedAt.Valid)
+			}
+		},
+	)
+}
+
+func TestBearerToken(t *testing.T) {
+	for value, expected := range map[string]string{
+		"Bearer arc_token": "arc_token",
+		"bearer arc_token": "arc_token",
+		"Basic arc_token":  "",
+		"Bearer ":          "",
+		"":                 "",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", value)
+		assert.Equal(t, expected, GetBearerToken(req), value)
+	}
+	assert.True(t, Session{}.HasScope("read"))
+	assert.True(t, Session{Scopes: []string{ScopeAll}}.HasScope("read"))
+	assert.False(t, Session{Scopes: []string{}}.HasScope("read"))
+}
+
+func TestTokenTable(t *testing.T) {
+	schema := UserSchema{Table: "accounts", Columns: map[string]string{quirk.Id: "account_id"}}
+	fields := referenceUserTable(pgTokenFields, TokenUserId, schema)
+	assert.Equal(t, "int not null references accounts (account_id) on delete cascade", fields[1].Props)
+	assert.Equal(t, "int not null references users (id) on delete cascade", referenceUserTable(pgTokenFields, TokenUserId, UserSchema{})[1].Props)
+	assert.Equal(t, "int not null", pgTokenFields[1].Props)
+}
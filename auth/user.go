@@ -2,10 +2,7 @@ package auth
 
 import (
 	"database/sql"
-	"fmt"
-	"reflect"
 	"slices"
-	"strings"
 	"time"
 	
 	"github.com/dchest/uniuri"
@@ -20,6 +17,7 @@ type UserManager interface {
 	Exists(id ...int) (bool, error)
 	Get(id ...int) (User, error)
 	Create(r User) (int, error)
+	Import(r User) (int, error)
 	Update(r User, columns ...string) error
 	ResetPassword(token ...string) (string, error)
 	DestroyResetPassword(token string) error
@@ -31,6 +29,7 @@ type UserManager interface {
 	MustExists(id ...int) bool
 	MustGet(id ...int) User
 	MustCreate(r User) int
+	MustImport(r User) int
 	MustUpdate(r User, columns ...string)
 	MustResetPassword(token ...string) string
 	MustDestroyResetPassword(token string)
@@ -41,39 +40,53 @@ type UserManager interface {
 }
 
 type User struct {
-	Id           int              `json:"id"`
-	Active       bool             `json:"active"`
-	Roles        []string         `json:"roles"`
-	Email        string           `json:"email"`
-	Password     string           `json:"password"`
-	Tfa          bool             `json:"tfa"`
-	TfaSecret    sql.Null[string] `json:"tfaSecret"`
-	TfaCodes     sql.Null[string] `json:"tfaCodes"`
-	TfaUrl       sql.Null[string] `json:"tfaUrl"`
-	LastActivity time.Time        `json:"lastActivity"`
-	CreatedAt    time.Time        `json:"createdAt"`
-	UpdatedAt    time.Time        `json:"updatedAt"`
+	Id              int                 `json:"id"`
+	Active          bool                `json:"active"`
+	Roles           []string            `json:"roles"`
+	Email           string              `json:"email"`
+	Password        string              `json:"password"`
+	Tfa             bool                `json:"tfa"`
+	TfaSecret       sql.Null[string]    `json:"tfaSecret"`
+	TfaCodes        sql.Null[string]    `json:"tfaCodes"`
+	TfaUrl          sql.Null[string]    `json:"tfaUrl"`
+	EmailVerifiedAt sql.Null[time.Time] `json:"emailVerifiedAt"`
+	FirstName       string              `json:"firstName"`
+	LastName        string              `json:"lastName"`
+	Locale          string              `json:"locale"`
+	TenantId        int                 `json:"tenantId"`
+	LastActivity    time.Time           `json:"lastActivity"`
+	CreatedAt       time.Time           `json:"createdAt"`
+	UpdatedAt       time.Time           `json:"updatedAt"`
 }
 
 type userManager struct {
-	db         *quirk.DB
-	cache      cache.Client
-	id         int
-	email      string
-	driverName string
-	data       quirk.Map
+	store   UserStore
+	cache   cache.Client
+	id      int
+	email   string
+	data    quirk.Map
+	session SessionManager
+	policy  passwordPolicy
+	revoke  bool
+	emit    func(event Event)
+	forget  func(userId int) error
 }
 
 const (
-	UserActive       = "active"
-	UserRoles        = "roles"
-	UserEmail        = "email"
-	UserPassword     = "password"
-	UserTfa          = "tfa"
-	UserTfaSecret    = "tfa_secret"
-	UserTfaCodes     = "tfa_codes"
-	UserTfaUrl       = "tfa_url"
-	UserLastActivity = "last_activity"
+	UserActive          = "active"
+	UserRoles           = "roles"
+	UserEmail           = "email"
+	UserPassword        = "password"
+	UserTfa             = "tfa"
+	UserTfaSecret       = "tfa_secret"
+	UserTfaCodes        = "tfa_codes"
+	UserTfaUrl          = "tfa_url"
+	UserLastActivity    = "last_activity"
+	UserEmailVerifiedAt = "email_verified_at"
+	UserFirstName       = "first_name"
+	UserLastName        = "last_name"
+	UserLocale          = "locale"
+	UserTenantId        = "tenant_id"
 )
 
 const (
@@ -83,21 +96,23 @@ const (
 
 const (
 	operationInsert = "insert"
+	operationImport = "import"
 	operationUpdate = "update"
 )
 
-var (
-	argon = argon2.DefaultConfig()
-)
-
 func CreateUserManager(db *quirk.DB, cache cache.Client, id int, email string) UserManager {
+	u := CreateCustomUserManager(CreateUserStore(db, UserSchema{}), cache, id, email).(*userManager)
+	u.policy = createPasswordPolicy(db, Password{})
+	return u
+}
+
+func CreateCustomUserManager(store UserStore, cache cache.Client, id int, email string) UserManager {
 	return &userManager{
-		db:         db,
-		cache:      cache,
-		email:      email,
-		id:         id,
-		data:       make(map[string]any),
-		driverName: db.DriverName(),
+		store: store,
+		cache: cache,
+		email: email,
+		id:    id,
+		data:  make(map[string]any),
 	}
 }
 
@@ -121,16 +136,7 @@ func (u *userManager) Get(id ...int) (User, error) {
 	if len(id) > 0 {
 		u.id = id[0]
 	}
-	var r User
-	if u.id == 0 && u.email == "" {
-		return r, ErrorInvalidUser
-	}
-	err := quirk.New(u.db).Q(`SELECT *`).
-		Q(fmt.Sprintf(`FROM %s`, usersTable)).
-		If(u.id > 0, `WHERE id = @id`, quirk.Map{"id": u.id}).
-		If(u.id == 0, `WHERE email = @email`, quirk.Map{"email": u.email}).
-		Q(`LIMIT 1`).
-		Exec(&r)
+	r, err := u.store.Get(u.id, u.email)
 	clear(u.data)
 	return r, err
 }
@@ -144,21 +150,7 @@ func (u *userManager) MustGet(id ...int) User {
 }
 
 func (u *userManager) Create(r User) (int, error) {
-	if u.id != 0 {
-		return u.id, ErrorUserAlreadyExists
-	}
-	if err := u.readData(operationInsert, r, []string{}); err != nil {
-		return 0, err
-	}
-	columns, placeholders := u.insertValues()
-	err := quirk.New(u.db).Q(fmt.Sprintf(`INSERT INTO %s`, usersTable)).
-		Q(fmt.Sprintf(`(%s)`, columns)).
-		Q(fmt.Sprintf(`VALUES (%s)`, placeholders), u.args()...).
-		Q(`RETURNING id`).
-		Exec(&u.id)
-	u.email = r.Email
-	clear(u.data)
-	return u.id, err
+	return u.create(operationInsert, r)
 }
 
 func (u *userManager) MustCreate(r User) int {
@@ -169,6 +161,35 @@ func (u *userManager) MustCreate(r User) int {
 	return id
 }
 
+func (u *userManager) Import(r User) (int, error) {
+	return u.create(operationImport, r)
+}
+
+func (u *userManager) MustImport(r User) int {
+	id, err := u.Import(r)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+func (u *userManager) create(operation string, r User) (int, error) {
+	if u.id != 0 {
+		return u.id, ErrorUserAlreadyExists
+	}
+	if err := u.readData(operation, r, []string{}); err != nil {
+		return 0, err
+	}
+	id, err := u.store.Create(u.data)
+	u.id, u.email = id, r.Email
+	hash, _ := u.data[UserPassword].(string)
+	clear(u.data)
+	if err != nil {
+		return u.id, err
+	}
+	return u.id, u.policy.remember(u.id, hash)
+}
+
 func (u *userManager) Update(r User, columns ...string) error {
 	if u.id == 0 && u.email == "" {
 		return ErrorInvalidUser
@@ -176,13 +197,36 @@ func (u *userManager) Update(r User, columns ...string) error {
 	if err := u.readData(operationUpdate, r, columns); err != nil {
 		return err
 	}
-	err := quirk.New(u.db).Q(fmt.Sprintf(`UPDATE %s`, usersTable)).
-		Q(fmt.Sprintf(`SET %s`, u.updateValues()), u.args()...).
-		If(u.id > 0, `WHERE id = @id`, quirk.Map{"id": u.id}).
-		If(u.id == 0, `WHERE email = @email`, quirk.Map{"email": u.email}).
-		Exec()
+	if len(columns) > 0 && !slices.Contains(columns, UserPassword) {
+		_, err := u.store.Update(u.id, u.email, u.data)
+		clear(u.data)
+		return err
+	}
+	user, err := u.store.Get(u.id, u.email)
+	if err != nil {
+		clear(u.data)
+		return err
+	}
+	changed := user.Password != r.Password
+	if changed {
+		if user.Id == 0 {
+			clear(u.data)
+			return ErrorMissingUser
+		}
+		// New password values go through changePassword, like UpdatePassword does.
+		delete(u.data, UserPassword)
+		if len(columns) == 0 || slices.Contains(columns, UserEmail) {
+			user.Email = r.Email
+		}
+	}
+	if len(u.data) > 0 {
+		_, err = u.store.Update(u.id, u.email, u.data)
+	}
 	clear(u.data)
-	return err
+	if err != nil || !changed {
+		return err
+	}
+	return u.changePassword(user, r.Password)
 }
 
 func (u *userManager) MustUpdate(r User, columns ...string) {
@@ -237,17 +281,7 @@ func (u *userManager) UpdatePassword(actualPassword, newPassword string) error {
 	if ok, err := argon2.VerifyEncoded([]byte(actualPassword), []byte(user.Password)); !ok || err != nil {
 		return ErrorMismatchPassword
 	}
-	hash, err := u.hashPassword(newPassword)
-	if err != nil {
-		return err
-	}
-	err = quirk.New(u.db).Q(fmt.Sprintf(`UPDATE %s`, usersTable)).
-		Q(`SET password = @password`, quirk.Map{"password": hash}).
-		If(u.id > 0, `WHERE id = @id`, quirk.Map{"id": u.id}).
-		If(u.id == 0, `WHERE email = @email`, quirk.Map{"email": u.email}).
-		Exec()
-	clear(u.data)
-	return err
+	return u.changePassword(user, newPassword)
 }
 
 func (u *userManager) MustUpdatePassword(actualPassword, newPassword string) {
@@ -261,17 +295,14 @@ func (u *userManager) ForceUpdatePassword(newPassword string) error {
 	if u.id == 0 && u.email == "" {
 		return ErrorMissingUser
 	}
-	hash, err := u.hashPassword(newPassword)
+	user, err := u.Get()
 	if err != nil {
 		return err
 	}
-	err = quirk.New(u.db).Q(fmt.Sprintf(`UPDATE %s`, usersTable)).
-		Q(`SET password = @password`, quirk.Map{"password": hash}).
-		If(u.id > 0, `WHERE id = @id`, quirk.Map{"id": u.id}).
-		If(u.id == 0, `WHERE email = @email`, quirk.Map{"email": u.email}).
-		Exec()
-	clear(u.data)
-	return err
+	if user.Id == 0 {
+		return ErrorMissingUser
+	}
+	return u.changePassword(user, newPassword)
 }
 
 func (u *userManager) MustForceUpdatePassword(newPassword string) {
@@ -288,11 +319,7 @@ func (u *userManager) Enable(id ...int) error {
 	if u.id == 0 && u.email == "" {
 		return ErrorInvalidUser
 	}
-	err := quirk.New(u.db).Q(fmt.Sprintf(`UPDATE %s`, usersTable)).
-		Q(`SET active = true`).
-		If(u.id > 0, `WHERE id = @id`, quirk.Map{"id": u.id}).
-		If(u.id == 0, `WHERE email = @email`, quirk.Map{"email": u.email}).
-		Exec()
+	_, err := u.store.Update(u.id, u.email, map[string]any{UserActive: true})
 	clear(u.data)
 	return err
 }
@@ -311,11 +338,7 @@ func (u *userManager) Disable(id ...int) error {
 	if u.id == 0 && u.email == "" {
 		return ErrorInvalidUser
 	}
-	err := quirk.New(u.db).Q(fmt.Sprintf(`UPDATE %s`, usersTable)).
-		Q(`SET active = false`).
-		If(u.id > 0, `WHERE id = @id`, quirk.Map{"id": u.id}).
-		If(u.id == 0, `WHERE email = @email`, quirk.Map{"email": u.email}).
-		Exec()
+	_, err := u.store.Update(u.id, u.email, map[string]any{UserActive: false})
 	clear(u.data)
 	return err
 }
@@ -329,7 +352,7 @@ func (u *userManager) MustDisable(id ...int) {
 
 func (u *userManager) readData(operation string, data User, columns []string) error {
 	columnsExist := len(columns) > 0
-	if operation == operationInsert && slices.Contains(columns, quirk.Id) {
+	if operation != operationUpdate && slices.Contains(columns, quirk.Id) {
 		u.data[quirk.Id] = data.Id
 	}
 	if !columnsExist || slices.Contains(columns, UserActive) {
@@ -338,8 +361,11 @@ func (u *userManager) readData(operation string, data User, columns []string) er
 	if !columnsExist || slices.Contains(columns, UserEmail) {
 		u.data[UserEmail] = data.Email
 	}
-	if !columnsExist || slices.Contains(columns, UserPassword) {
-		hash, err := u.hashPassword(data.Password)
+	if operation == operationUpdate && (!columnsExist || slices.Contains(columns, UserPassword)) {
+		u.data[UserPassword] = data.Password
+	}
+	if operation != operationUpdate && (!columnsExist || slices.Contains(columns, UserPassword)) {
+		hash, err := u.readPassword(operation, data.Password)
 		if err != nil {
 			return err
 		}
@@ -360,91 +386,92 @@ func (u *userManager) readData(operation string, data User, columns []string) er
 	if !columnsExist || slices.Contains(columns, UserTfaUrl) {
 		u.data[UserTfaUrl] = data.TfaUrl.V
 	}
+	if !columnsExist || slices.Contains(columns, UserFirstName) {
+		u.data[UserFirstName] = data.FirstName
+	}
+	if !columnsExist || slices.Contains(columns, UserLastName) {
+		u.data[UserLastName] = data.LastName
+	}
+	if !columnsExist || slices.Contains(columns, UserLocale) {
+		u.data[UserLocale] = data.Locale
+	}
+	if !columnsExist || slices.Contains(columns, UserTenantId) {
+		u.data[UserTenantId] = data.TenantId
+	}
 	return nil
 }
 
-func (u *userManager) hashPassword(password string) (string, error) {
-	if strings.HasPrefix(password, "$argon2") {
+func (u *userManager) readPassword(operation, password string) (string, error) {
+	if operation == operationImport {
+		if _, err := argon2.Decode([]byte(password)); err != nil {
+			return "", ErrorInvalidPasswordHash
+		}
 		return password, nil
 	}
-	hash, err := argon.HashEncoded([]byte(password))
-	if err != nil {
-		return "", err
+	// Deprecated: pre-hashed values passed to Create are kept as they are for
+	// backwards compatibility, use Import for migrating existing hashes.
+	if _, err := argon2.Decode([]byte(password)); err == nil {
+		return password, nil
 	}
-	return string(hash), nil
+	return u.policy.create(password)
 }
 
-func (u *userManager) args() []quirk.Map {
-	if len(u.data) == 0 {
-		return []quirk.Map{}
-	}
-	result := u.data
-	vectors := make([]any, 0)
-	for name, v := range u.data {
-		kind := reflect.TypeOf(v).Kind()
-		if name == UserPassword || kind == reflect.Bool {
-			continue
-		}
-		vectors = append(vectors, v)
+func (u *userManager) revokeSessions(id int) error {
+	if !u.revoke || u.session == nil {
+		return nil
 	}
-	switch u.driverName {
-	case quirk.Postgres:
-		if len(vectors) > 0 {
-			result[quirk.Vectors] = quirk.CreateTsVector(vectors...)
+	if id == 0 {
+		user, err := u.Get()
+		if err != nil {
+			return err
 		}
+		id = user.Id
 	}
-	return []quirk.Map{result}
+	return u.session.RevokeAll(id, true)
 }
 
-func (u *userManager) insertValues() (string, string) {
-	columns := []string{quirk.Id}
-	placeholders := []string{quirk.Default}
-	for name := range u.data {
-		columns = append(columns, name)
-		placeholders = append(placeholders, paramPrefix+name)
+func (u *userManager) forgetCredentials(id int) error {
+	if u.forget == nil {
+		return nil
 	}
-	switch u.driverName {
-	case quirk.Postgres:
-		if len(u.data) > 0 {
-			columns = append(columns, quirk.Vectors)
-			placeholders = append(placeholders, fmt.Sprintf("to_tsvector(%s%s)", paramPrefix, quirk.Vectors))
-		}
+	return u.forget(id)
+}
+
+func (u *userManager) passwordChanged(id int, email string) {
+	if u.emit == nil {
+		return
 	}
-	columns = append(columns, UserLastActivity)
-	placeholders = append(placeholders, quirk.CurrentTimestamp)
-	
-	columns = append(columns, quirk.CreatedAt)
-	placeholders = append(placeholders, quirk.CurrentTimestamp)
-	
-	columns = append(columns, quirk.UpdatedAt)
-	placeholders = append(placeholders, quirk.CurrentTimestamp)
-	return strings.Join(columns, ","), strings.Join(placeholders, ",")
+	u.emit(Event{Name: EventPasswordChange, UserId: id, Email: email})
 }
 
-func (u *userManager) updateValues() string {
-	result := make([]string, 0)
-	for column := range u.data {
-		if column == quirk.Id {
-			continue
-		}
-		result = append(result, fmt.Sprintf("%s = %s%s", column, paramPrefix, column))
-	}
-	result = append(result, fmt.Sprintf("%s = %s", UserLastActivity, quirk.CurrentTimestamp))
-	result = append(result, fmt.Sprintf("%s = %s", quirk.UpdatedAt, quirk.CurrentTimestamp))
-	switch u.driverName {
-	case quirk.Postgres:
-		vectors := make([]any, 0)
-		for column, v := range u.data {
-			if column == quirk.Id {
-				continue
-			}
-			vectors = append(vectors, v)
-		}
-		if len(vectors) > 0 {
-			result = append(result, fmt.Sprintf("%s = to_tsvector(%s%s)", quirk.Vectors, paramPrefix, quirk.Vectors))
-		}
+func (u *userManager) changePassword(user User, password string) error {
+	reused, err := u.policy.reused(user.Id, password, user.Password)
+	if err != nil {
+		return err
+	}
+	if reused {
+		return ErrorPasswordReused
+	}
+	hash, err := u.policy.create(password)
+	if err != nil {
+		return err
 	}
-	return strings.Join(result, ",")
+	_, err = u.store.Update(user.Id, "", map[string]any{UserPassword: hash})
+	clear(u.data)
+	if err != nil {
+		return err
+	}
+	if err := u.policy.remember(user.Id, hash); err != nil {
+		return err
+	}
+	if err := u.revokeSessions(user.Id); err != nil {
+		return err
+	}
+	if err := u.forgetCredentials(user.Id); err != nil {
+		return err
+	}
+	u.passwordChanged(user.Id, user.Email)
+	return nil
 }
 
 func (u *userManager) createResetPasswordKey(token string) string {
@@ -4,19 +4,17 @@ import (
 	"testing"
 	
 	"github.com/stretchr/testify/assert"
-	
-	"github.com/daarlabs/arcanum/quirk"
 )
 
 func TestUser(t *testing.T) {
 	db := createTestDatabaseConnection(t)
 	assert.NotNil(t, db)
-	assert.NoError(t, DropTable(quirk.New(db)))
-	assert.NoError(t, CreateTable(quirk.New(db)))
+	assert.NoError(t, DropTable(db))
+	assert.NoError(t, CreateTable(db))
 	um := CreateUserManager(db, nil, 0, "")
 	t.Cleanup(
 		func() {
-			assert.NoError(t, DropTable(quirk.New(db)))
+			assert.NoError(t, DropTable(db))
 		},
 	)
 	t.Run(
@@ -1,16 +1,123 @@
 package auth
 
-import "fmt"
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
 
 const (
-	SessionCacheKey = "session"
-	TfaCacheKey     = "tfa"
+	SessionCacheKey        = "session"
+	SessionUserCacheKey    = "session-user"
+	SessionRefreshCacheKey = "session-refresh"
+	SessionDenyCacheKey    = "session-deny"
+	TfaCacheKey            = "tfa"
+	TfaOtpCacheKey         = "tfa-otp"
+	TfaTrustCacheKey       = "tfa-trust"
+	ThrottleCacheKey       = "throttle"
+	OidcCacheKey           = "oidc"
+	EmailTokenCacheKey     = "email-token"
+	RememberCacheKey       = "remember"
+	ImpersonationCacheKey  = "impersonation"
 )
 
 func createSessionCacheKey(token string) string {
 	return fmt.Sprintf("%s:%s", SessionCacheKey, token)
 }
 
+func createSessionUserCacheKey(userId int, token string) string {
+	return fmt.Sprintf("%s:%d:%s", SessionUserCacheKey, userId, token)
+}
+
+func createSessionRefreshCacheKey(sid string) string {
+	return fmt.Sprintf("%s:%s", SessionRefreshCacheKey, sid)
+}
+
+func createSessionRefreshUsedCacheKey(sid, hash string) string {
+	return fmt.Sprintf("%s:used:%s:%s", SessionRefreshCacheKey, sid, hash)
+}
+
+func createSessionRefreshGraceCacheKey(sid, hash string) string {
+	return fmt.Sprintf("%s:grace:%s:%s", SessionRefreshCacheKey, sid, hash)
+}
+
+func createSessionDenyCacheKey(sid string) string {
+	return fmt.Sprintf("%s:%s", SessionDenyCacheKey, sid)
+}
+
+func createRememberCacheKey(hash string) string {
+	return fmt.Sprintf("%s:%s", RememberCacheKey, hash)
+}
+
+func createThrottleAttemptCacheKey(subject, value string) string {
+	return fmt.Sprintf("%s:attempt:%s:%s", ThrottleCacheKey, subject, value)
+}
+
+func createThrottleBlockCacheKey(subject, value string) string {
+	return fmt.Sprintf("%s:block:%s:%s", ThrottleCacheKey, subject, value)
+}
+
+func createOidcCacheKey(state string) string {
+	return fmt.Sprintf("%s:%s", OidcCacheKey, state)
+}
+
+func createEmailTokenCacheKey(flow, nonce string) string {
+	return fmt.Sprintf("%s:%s:%s", EmailTokenCacheKey, flow, nonce)
+}
+
+func createEmailTokenClaimCacheKey(flow, nonce string) string {
+	return fmt.Sprintf("%s:claim:%s:%s", EmailTokenCacheKey, flow, nonce)
+}
+
+func createTfaOtpCacheKey(token string) string {
+	return fmt.Sprintf("%s:%s", TfaOtpCacheKey, token)
+}
+
+func createTfaTrustCacheKey(userId int, nonce string) string {
+	return fmt.Sprintf("%s:%d:%s", TfaTrustCacheKey, userId, nonce)
+}
+
 func createTfaCacheKey(token string) string {
 	return fmt.Sprintf("%s:%s", TfaCacheKey, token)
 }
+
+func createImpersonationCacheKey(id int) string {
+	return fmt.Sprintf("%s:%d", ImpersonationCacheKey, id)
+}
+
+func getRequestIp(req *http.Request, proxies []string) string {
+	ip, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		ip = req.RemoteAddr
+	}
+	if !isTrustedProxy(ip, proxies) {
+		return ip
+	}
+	forwarded := strings.Split(req.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		if hop := strings.TrimSpace(forwarded[i]); len(hop) > 0 {
+			ip = hop
+		}
+		if !isTrustedProxy(ip, proxies) {
+			return ip
+		}
+	}
+	return ip
+}
+
+func isTrustedProxy(ip string, proxies []string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, proxy := range proxies {
+		if _, network, err := net.ParseCIDR(proxy); err == nil && network.Contains(addr) {
+			return true
+		}
+		if trusted := net.ParseIP(proxy); trusted != nil && trusted.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
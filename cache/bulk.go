@@ -0,0 +1,273 @@
+package cache
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+	
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	scanCount = 100
+)
+
+var (
+	patternEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+)
+
+func (c cache) GetMany(keys []string, data any) error {
+	if c.isNil() {
+		return ErrorAdapterInstanceNotExist
+	}
+	target := reflect.ValueOf(data)
+	if target.Kind() != reflect.Ptr || target.Elem().Kind() != reflect.Map {
+		return ErrorInvalidManyTarget
+	}
+	if target.Elem().Type().Key().Kind() != reflect.String {
+		return ErrorInvalidManyTarget
+	}
+	m := target.Elem()
+	if m.IsNil() {
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+	values, err := c.getValues(c.keys(keys...)...)
+	if err != nil {
+		return err
+	}
+	for key, value := range values {
+		item := reflect.New(m.Type().Elem())
+		if err := c.decode(value, item.Interface()); err != nil {
+			return err
+		}
+		m.SetMapIndex(reflect.ValueOf(c.trimKey(key)).Convert(m.Type().Key()), item.Elem())
+	}
+	return nil
+}
+
+func (c cache) MustGetMany(keys []string, data any) {
+	if err := c.GetMany(keys, data); err != nil {
+		panic(err)
+	}
+}
+
+func (c cache) SetMany(data map[string]any, expiration time.Duration, tags ...string) error {
+	if c.isNil() {
+		return ErrorAdapterInstanceNotExist
+	}
+	values := make(map[string]string, len(data))
+	for key, item := range data {
+		value, err := c.encode(item)
+		if err != nil {
+			return err
+		}
+		values[c.key(key)] = value
+	}
+	expiration, tags = c.ttl(expiration), c.keys(tags...)
+	switch c.adapter {
+	case AdapterMemory:
+		return c.memory.SetMany(values, expiration, tags...)
+	case AdapterRedis:
+		return c.setRedisValues(values, expiration, tags...)
+	case AdapterLayered:
+		if err := c.setRedisValues(values, expiration, tags...); err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		return c.invalidateLocal(keys...)
+	}
+	return nil
+}
+
+func (c cache) MustSetMany(data map[string]any, expiration time.Duration, tags ...string) {
+	if err := c.SetMany(data, expiration, tags...); err != nil {
+		panic(err)
+	}
+}
+
+func (c cache) DestroyMany(keys ...string) error {
+	return c.destroyMany(c.keys(keys...)...)
+}
+
+func (c cache) MustDestroyMany(keys ...string) {
+	if err := c.DestroyMany(keys...); err != nil {
+		panic(err)
+	}
+}
+
+func (c cache) destroyMany(keys ...string) error {
+	if c.isNil() {
+		return ErrorAdapterInstanceNotExist
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	switch c.adapter {
+	case AdapterMemory:
+		return c.memory.DestroyMany(keys...)
+	case AdapterRedis:
+		return c.redis.Del(c.ctx, keys...).Err()
+	case AdapterLayered:
+		if err := c.redis.Del(c.ctx, keys...).Err(); err != nil {
+			return err
+		}
+		return c.invalidateLocal(keys...)
+	}
+	return nil
+}
+
+func (c cache) DestroyPrefix(prefix string) error {
+	return c.destroyPrefix(c.key(prefix))
+}
+
+func (c cache) MustDestroyPrefix(prefix string) {
+	if err := c.DestroyPrefix(prefix); err != nil {
+		panic(err)
+	}
+}
+
+func (c cache) destroyPrefix(prefix string) error {
+	if c.isNil() {
+		return ErrorAdapterInstanceNotExist
+	}
+	switch c.adapter {
+	case AdapterMemory:
+		_, err := c.memory.DestroyPrefix(prefix)
+		return err
+	case AdapterRedis, AdapterLayered:
+		keys, err := c.scanRedisKeys(patternEscaper.Replace(prefix) + "*")
+		if err != nil {
+			return err
+		}
+		for i := 0; i < len(keys); i += scanCount {
+			if err := c.destroyMany(keys[i:min(i+scanCount, len(keys))]...); err != nil {
+				return err
+			}
+		}
+		if c.adapter == AdapterLayered {
+			keys, err := c.memory.DestroyPrefix(prefix)
+			if err != nil {
+				return err
+			}
+			return c.publishInvalidation(keys...)
+		}
+	}
+	return nil
+}
+
+func (c cache) Keys(pattern string) ([]string, error) {
+	if c.isNil() {
+		return nil, ErrorAdapterInstanceNotExist
+	}
+	var keys []string
+	var err error
+	pattern = patternEscaper.Replace(c.namespace) + pattern
+	switch c.adapter {
+	case AdapterMemory:
+		keys, err = c.memory.Keys(pattern)
+	case AdapterRedis, AdapterLayered:
+		keys, err = c.scanRedisKeys(pattern)
+	}
+	result := make([]string, len(keys))
+	for i, key := range keys {
+		result[i] = c.trimKey(key)
+	}
+	return result, err
+}
+
+func (c cache) MustKeys(pattern string) []string {
+	keys, err := c.Keys(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return keys
+}
+
+func (c cache) getValues(keys ...string) (map[string]string, error) {
+	result := make(map[string]string)
+	if len(keys) == 0 {
+		return result, nil
+	}
+	switch c.adapter {
+	case AdapterMemory:
+		return c.memory.GetMany(keys...), nil
+	case AdapterRedis:
+		return c.getRedisValues(keys...)
+	case AdapterLayered:
+		missing := make([]string, 0)
+		for key, value := range c.memory.GetMany(keys...) {
+			result[key] = value
+		}
+		for _, key := range keys {
+			if _, ok := result[key]; !ok {
+				missing = append(missing, key)
+			}
+		}
+		values, err := c.getRedisValues(missing...)
+		if err != nil {
+			return result, err
+		}
+		ttls, err := c.getRedisTtls(missing...)
+		if err != nil {
+			return result, err
+		}
+		for key, value := range values {
+			result[key] = value
+			if ttls[key] == redisMissingTtl {
+				continue
+			}
+			if err := c.memory.Set(key, value, c.localExpiration(ttls[key])); err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, nil
+}
+
+func (c cache) getRedisValues(keys ...string) (map[string]string, error) {
+	result := make(map[string]string)
+	if len(keys) == 0 {
+		return result, nil
+	}
+	values, err := c.redis.MGet(c.ctx, keys...).Result()
+	if err != nil {
+		return result, err
+	}
+	for i, value := range values {
+		v, ok := value.(string)
+		if !ok || len(v) == 0 {
+			continue
+		}
+		result[keys[i]] = v
+	}
+	return result, nil
+}
+
+func (c cache) setRedisValues(values map[string]string, expiration time.Duration, tags ...string) error {
+	_, err := c.redis.Pipelined(
+		c.ctx, func(pipe redis.Pipeliner) error {
+			for key, value := range values {
+				pipe.Set(c.ctx, key, value, expiration)
+				for _, tag := range tags {
+					redisTagScript.Eval(c.ctx, pipe, []string{createTagCacheKey(tag)}, key, expiration.Milliseconds())
+				}
+			}
+			return nil
+		},
+	)
+	return err
+}
+
+func (c cache) scanRedisKeys(pattern string) ([]string, error) {
+	keys := make([]string, 0)
+	iter := c.redis.Scan(c.ctx, 0, pattern, scanCount).Iterator()
+	for iter.Next(c.ctx) {
+		keys = append(keys, iter.Val())
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys), iter.Err()
+}
@@ -2,12 +2,13 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"os"
+	"sync"
 	"time"
 	
 	"github.com/go-redis/redis/v8"
+	"golang.org/x/sync/singleflight"
 	
 	"github.com/daarlabs/arcanum/cache/memory"
 )
@@ -15,12 +16,36 @@ import (
 type Client interface {
 	Exists(key string) bool
 	Get(key string, data any) error
-	Set(key string, data any, expiration time.Duration) error
+	Set(key string, data any, expiration time.Duration, tags ...string) error
 	Destroy(key string) error
+	InvalidateTags(tags ...string) error
+	Remember(key string, expiration time.Duration, loader func() (any, error), target any) error
+	SetNX(key string, data any, expiration time.Duration) (bool, error)
+	Increment(key string, value int64, expiration time.Duration) (int64, error)
+	Decrement(key string, value int64, expiration time.Duration) (int64, error)
+	Lock(key string, expiration time.Duration) (Lock, error)
+	GetMany(keys []string, data any) error
+	SetMany(data map[string]any, expiration time.Duration, tags ...string) error
+	DestroyMany(keys ...string) error
+	DestroyPrefix(prefix string) error
+	Keys(pattern string) ([]string, error)
+	Namespace(name string, expiration ...time.Duration) Client
+	Flush() error
 	
 	MustGet(key string, data any)
-	MustSet(key string, data any, expiration time.Duration)
+	MustSet(key string, data any, expiration time.Duration, tags ...string)
 	MustDestroy(key string)
+	MustInvalidateTags(tags ...string)
+	MustRemember(key string, expiration time.Duration, loader func() (any, error), target any)
+	MustSetNX(key string, data any, expiration time.Duration) bool
+	MustIncrement(key string, value int64, expiration time.Duration) int64
+	MustDecrement(key string, value int64, expiration time.Duration) int64
+	MustGetMany(keys []string, data any)
+	MustSetMany(data map[string]any, expiration time.Duration, tags ...string)
+	MustDestroyMany(keys ...string)
+	MustDestroyPrefix(prefix string)
+	MustKeys(pattern string) []string
+	MustFlush()
 }
 
 type cache struct {
@@ -28,75 +53,138 @@ type cache struct {
 	adapter string
 	memory  *memory.Client
 	redis   *redis.Client
+	local   time.Duration
+	
+	namespace  string
+	expiration time.Duration
+	
+	codec                Codec
+	compression          Compression
+	compressionThreshold int
 }
 
 const (
-	AdapterMemory = "memory"
-	AdapterRedis  = "redis"
+	AdapterMemory  = "memory"
+	AdapterRedis   = "redis"
+	AdapterLayered = "layered"
+)
+
+const (
+	tagCacheKey  = "cache-tag"
+	lockCacheKey = "cache-lock"
+)
+
+const (
+	rememberLockDuration = 10 * time.Second
+	rememberPollInterval = 25 * time.Millisecond
 )
 
 var (
 	defaultMemoryCacheDir = os.TempDir() + "/.arcanum/cache/"
+	defaultMemory         = sync.OnceValue(
+		func() *memory.Client {
+			return memory.New(defaultMemoryCacheDir)
+		},
+	)
+	rememberGroup = &singleflight.Group{}
+)
+
+var (
+	redisIncrementScript = redis.NewScript(
+		`
+local n = redis.call('INCRBY', KEYS[1], ARGV[1])
+local expiration = tonumber(ARGV[2])
+if expiration > 0 and redis.call('PTTL', KEYS[1]) == -1 then
+	redis.call('PEXPIRE', KEYS[1], expiration)
+end
+return n
+`,
+	)
+	redisTagScript = redis.NewScript(
+		`
+local ttl = redis.call('PTTL', KEYS[1])
+redis.call('SADD', KEYS[1], ARGV[1])
+local expiration = tonumber(ARGV[2])
+if expiration <= 0 then
+	redis.call('PERSIST', KEYS[1])
+elseif ttl ~= -1 and ttl < expiration then
+	redis.call('PEXPIRE', KEYS[1], expiration)
+end
+return 1
+`,
+	)
 )
 
 var (
 	ErrorAdapterInstanceNotExist = errors.New("cache adapter instance not exist")
+	ErrorLockNotAcquired         = errors.New("cache lock is already acquired")
+	ErrorLockNotHeld             = errors.New("cache lock is not held")
+	ErrorInvalidEncodedValue     = errors.New("cache value has invalid encoding")
+	ErrorInvalidManyTarget       = errors.New("target is not a pointer to map with string keys")
+	ErrorMissingNamespace        = errors.New("cache namespace is missing")
 )
 
-func New(ctx context.Context, mem *memory.Client, redis *redis.Client) Client {
-	var adapter string
+func New(ctx context.Context, mem *memory.Client, redis *redis.Client, configs ...Config) Client {
+	c := &cache{
+		ctx:    ctx,
+		memory: mem,
+		redis:  redis,
+		codec:  JsonCodec,
+	}
+	for _, item := range configs {
+		cfg, ok := item.(*config)
+		if !ok {
+			continue
+		}
+		switch cfg.name {
+		case configCodec:
+			if codec, ok := cfg.value.(Codec); ok && codec != nil {
+				c.codec = codec
+			}
+		case configCompression:
+			compression := cfg.value.(compressionConfig)
+			c.compression = compression.compression
+			c.compressionThreshold = compression.threshold
+		case configLayered:
+			c.local = cfg.value.(time.Duration)
+		}
+	}
 	if redis == nil {
-		if mem == nil {
-			mem = memory.New(defaultMemoryCacheDir)
+		if c.memory == nil {
+			c.memory = defaultMemory()
 		}
-		adapter = AdapterMemory
+		c.adapter = AdapterMemory
 	}
 	if redis != nil {
-		adapter = AdapterRedis
+		c.adapter = AdapterRedis
 	}
-	return &cache{
-		ctx:     ctx,
-		adapter: adapter,
-		memory:  mem,
-		redis:   redis,
+	if redis != nil && c.local > 0 {
+		if c.memory == nil {
+			c.memory = getLocal(redis)
+		}
+		c.adapter = AdapterLayered
+		c.subscribe()
 	}
+	return c
 }
 
 func (c cache) Exists(key string) bool {
 	if c.isNil() {
 		return false
 	}
-	switch c.adapter {
-	case AdapterMemory:
-		return c.memory.Exists(key)
-	case AdapterRedis:
-		cmd := c.redis.Exists(c.ctx, key)
-		if cmd == nil {
-			return false
-		}
-		return cmd.Val() > 0
-	default:
-		return false
-	}
+	return c.exists(c.key(key))
 }
 
 func (c cache) Get(key string, data any) error {
 	if c.isNil() {
 		return ErrorAdapterInstanceNotExist
 	}
-	var value string
-	switch c.adapter {
-	case AdapterMemory:
-		value = c.memory.Get(key)
-	case AdapterRedis:
-		stored := c.redis.Get(c.ctx, key)
-		if stored == nil {
-			return nil
-		}
-		value = stored.Val()
+	value, err := c.getValue(c.key(key))
+	if err != nil {
+		return err
 	}
 	if len(value) > 0 {
-		return json.Unmarshal([]byte(value), data)
+		return c.decode(value, data)
 	}
 	return nil
 }
@@ -107,46 +195,295 @@ func (c cache) MustGet(key string, data any) {
 	}
 }
 
-func (c cache) Set(key string, data any, expiration time.Duration) error {
+func (c cache) Set(key string, data any, expiration time.Duration, tags ...string) error {
 	if c.isNil() {
 		return ErrorAdapterInstanceNotExist
 	}
-	b, err := json.Marshal(data)
+	value, err := c.encode(data)
 	if err != nil {
 		return err
 	}
+	return c.setValue(c.key(key), value, c.ttl(expiration), c.keys(tags...)...)
+}
+
+func (c cache) MustSet(key string, data any, expiration time.Duration, tags ...string) {
+	if err := c.Set(key, data, expiration, tags...); err != nil {
+		panic(err)
+	}
+}
+
+func (c cache) Destroy(key string) error {
+	return c.destroyMany(c.key(key))
+}
+
+func (c cache) MustDestroy(key string) {
+	if err := c.Destroy(key); err != nil {
+		panic(err)
+	}
+}
+
+func (c cache) Remember(key string, expiration time.Duration, loader func() (any, error), target any) error {
+	if c.isNil() {
+		return ErrorAdapterInstanceNotExist
+	}
+	key = c.key(key)
+	value, err := c.getValue(key)
+	if err != nil {
+		return err
+	}
+	if len(value) > 0 {
+		return c.decode(value, target)
+	}
+	remembered, err, _ := rememberGroup.Do(
+		c.adapter+":"+key, func() (any, error) {
+			return c.remember(key, c.ttl(expiration), loader)
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return c.decode(remembered.(string), target)
+}
+
+func (c cache) MustRemember(key string, expiration time.Duration, loader func() (any, error), target any) {
+	if err := c.Remember(key, expiration, loader, target); err != nil {
+		panic(err)
+	}
+}
+
+func (c cache) SetNX(key string, data any, expiration time.Duration) (bool, error) {
+	if c.isNil() {
+		return false, ErrorAdapterInstanceNotExist
+	}
+	value, err := c.encode(data)
+	if err != nil {
+		return false, err
+	}
+	return c.setNXValue(c.key(key), value, c.ttl(expiration))
+}
+
+func (c cache) MustSetNX(key string, data any, expiration time.Duration) bool {
+	ok, err := c.SetNX(key, data, expiration)
+	if err != nil {
+		panic(err)
+	}
+	return ok
+}
+
+func (c cache) Increment(key string, value int64, expiration time.Duration) (int64, error) {
+	if c.isNil() {
+		return 0, ErrorAdapterInstanceNotExist
+	}
+	key, expiration = c.key(key), c.ttl(expiration)
 	switch c.adapter {
 	case AdapterMemory:
-		return c.memory.Set(key, string(b), expiration)
+		return c.memory.Increment(key, value, expiration)
 	case AdapterRedis:
-		if c.redis.Set(c.ctx, key, string(b), expiration).Err() != nil {
+		return redisIncrementScript.Run(c.ctx, c.redis, []string{key}, value, expiration.Milliseconds()).Int64()
+	case AdapterLayered:
+		n, err := redisIncrementScript.Run(c.ctx, c.redis, []string{key}, value, expiration.Milliseconds()).Int64()
+		if err != nil {
+			return n, err
+		}
+		return n, c.invalidateLocal(key)
+	}
+	return 0, nil
+}
+
+func (c cache) MustIncrement(key string, value int64, expiration time.Duration) int64 {
+	n, err := c.Increment(key, value, expiration)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func (c cache) Decrement(key string, value int64, expiration time.Duration) (int64, error) {
+	return c.Increment(key, -value, expiration)
+}
+
+func (c cache) MustDecrement(key string, value int64, expiration time.Duration) int64 {
+	n, err := c.Decrement(key, value, expiration)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func (c cache) InvalidateTags(tags ...string) error {
+	if c.isNil() {
+		return ErrorAdapterInstanceNotExist
+	}
+	tags = c.keys(tags...)
+	switch c.adapter {
+	case AdapterMemory:
+		return c.memory.InvalidateTags(tags...)
+	case AdapterRedis:
+		_, err := c.invalidateRedisTags(tags...)
+		return err
+	case AdapterLayered:
+		keys, err := c.invalidateRedisTags(tags...)
+		if err != nil {
 			return err
 		}
-		return nil
+		return c.invalidateLocal(keys...)
 	}
 	return nil
 }
 
-func (c cache) MustSet(key string, data any, expiration time.Duration) {
-	if err := c.Set(key, data, expiration); err != nil {
+func (c cache) MustInvalidateTags(tags ...string) {
+	if err := c.InvalidateTags(tags...); err != nil {
 		panic(err)
 	}
 }
 
-func (c cache) Destroy(key string) error {
+func (c cache) exists(key string) bool {
+	switch c.adapter {
+	case AdapterMemory:
+		return c.memory.Exists(key)
+	case AdapterRedis:
+		cmd := c.redis.Exists(c.ctx, key)
+		if cmd == nil {
+			return false
+		}
+		return cmd.Val() > 0
+	case AdapterLayered:
+		return c.memory.Exists(key) || c.redis.Exists(c.ctx, key).Val() > 0
+	default:
+		return false
+	}
+}
+
+func (c cache) getValue(key string) (string, error) {
+	switch c.adapter {
+	case AdapterMemory:
+		return c.memory.Get(key), nil
+	case AdapterRedis:
+		value, err := c.redis.Get(c.ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			return "", nil
+		}
+		return value, err
+	case AdapterLayered:
+		return c.getLayeredValue(key)
+	}
+	return "", nil
+}
+
+func (c cache) setValue(key string, value string, expiration time.Duration, tags ...string) error {
 	switch c.adapter {
 	case AdapterMemory:
-		return c.memory.Destroy(key)
+		return c.memory.Set(key, value, expiration, tags...)
 	case AdapterRedis:
-		return c.Set(key, nil, time.Millisecond)
+		return c.setRedisValue(key, value, expiration, tags...)
+	case AdapterLayered:
+		return c.setLayeredValue(key, value, expiration, tags...)
 	}
 	return nil
 }
 
-func (c cache) MustDestroy(key string) {
-	if err := c.Destroy(key); err != nil {
-		panic(err)
+func (c cache) setRedisValue(key string, value string, expiration time.Duration, tags ...string) error {
+	if err := c.redis.Set(c.ctx, key, value, expiration).Err(); err != nil {
+		return err
 	}
+	for _, tag := range tags {
+		if err := redisTagScript.Run(
+			c.ctx, c.redis, []string{createTagCacheKey(tag)}, key, expiration.Milliseconds(),
+		).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c cache) invalidateRedisTags(tags ...string) ([]string, error) {
+	result := make([]string, 0)
+	for _, tag := range tags {
+		tagKey := createTagCacheKey(tag)
+		keys, err := c.redis.SMembers(c.ctx, tagKey).Result()
+		if err != nil {
+			return result, err
+		}
+		if err := c.redis.Del(c.ctx, append(keys, tagKey)...).Err(); err != nil {
+			return result, err
+		}
+		result = append(result, keys...)
+	}
+	return result, nil
+}
+
+func (c cache) setNXValue(key string, value string, expiration time.Duration) (bool, error) {
+	switch c.adapter {
+	case AdapterMemory:
+		return c.memory.SetNX(key, value, expiration)
+	case AdapterRedis:
+		return c.redis.SetNX(c.ctx, key, value, expiration).Result()
+	case AdapterLayered:
+		ok, err := c.redis.SetNX(c.ctx, key, value, expiration).Result()
+		if err != nil || !ok {
+			return ok, err
+		}
+		return ok, c.invalidateLocal(key)
+	}
+	return false, nil
+}
+
+func (c cache) remember(key string, expiration time.Duration, loader func() (any, error)) (string, error) {
+	if c.adapter != AdapterMemory {
+		lockKey := createLockCacheKey(c.namespace + createRememberLockKey(c.trimKey(key)))
+		l, err := c.lock(lockKey, key, rememberLockDuration)
+		if err != nil && !errors.Is(err, ErrorLockNotAcquired) {
+			return "", err
+		}
+		if l != nil {
+			defer l.Release()
+		}
+		if l == nil {
+			value, err := c.awaitRemembered(key, lockKey)
+			if err != nil {
+				return "", err
+			}
+			if len(value) > 0 {
+				return value, nil
+			}
+		}
+	}
+	data, err := loader()
+	if err != nil {
+		return "", err
+	}
+	value, err := c.encode(data)
+	if err != nil {
+		return "", err
+	}
+	if err := c.setValue(key, value, expiration); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+func (c cache) awaitRemembered(key, lockKey string) (string, error) {
+	ticker := time.NewTicker(rememberPollInterval)
+	defer ticker.Stop()
+	deadline := time.Now().Add(rememberLockDuration)
+	for time.Now().Before(deadline) {
+		select {
+		case <-c.ctx.Done():
+			return "", c.ctx.Err()
+		case <-ticker.C:
+			value, err := c.getValue(key)
+			if err != nil {
+				return "", err
+			}
+			if len(value) > 0 {
+				return value, nil
+			}
+			if !c.exists(lockKey) {
+				return "", nil
+			}
+		}
+	}
+	return "", nil
 }
 
 func (c cache) isNil() bool {
@@ -155,7 +492,21 @@ func (c cache) isNil() bool {
 		return c.memory == nil
 	case AdapterRedis:
 		return c.redis == nil
+	case AdapterLayered:
+		return c.memory == nil || c.redis == nil
 	default:
 		return true
 	}
 }
+
+func createTagCacheKey(tag string) string {
+	return tagCacheKey + ":" + tag
+}
+
+func createLockCacheKey(key string) string {
+	return lockCacheKey + ":" + key
+}
+
+func createRememberLockKey(key string) string {
+	return "remember:" + key
+}
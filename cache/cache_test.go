@@ -0,0 +1,275 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	
+	"github.com/daarlabs/arcanum/cache/memory"
+)
+
+func TestCache(t *testing.T) {
+	c := New(context.Background(), memory.New(t.TempDir()), nil)
+	
+	t.Run(
+		"remember", func(t *testing.T) {
+			var calls atomic.Int32
+			var wg sync.WaitGroup
+			loader := func() (any, error) {
+				calls.Add(1)
+				time.Sleep(50 * time.Millisecond)
+				return map[string]int{"value": 42}, nil
+			}
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					var r map[string]int
+					assert.NoError(t, c.Remember("remember", time.Minute, loader, &r))
+					assert.Equal(t, 42, r["value"])
+				}()
+			}
+			wg.Wait()
+			assert.Equal(t, int32(1), calls.Load())
+			var r map[string]int
+			assert.NoError(t, c.Remember("remember", time.Minute, loader, &r))
+			assert.Equal(t, 42, r["value"])
+			assert.Equal(t, int32(1), calls.Load())
+		},
+	)
+	
+	t.Run(
+		"increment decrement", func(t *testing.T) {
+			n, err := c.Increment("counter", 2, time.Minute)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(2), n)
+			assert.Equal(t, int64(5), c.MustIncrement("counter", 3, time.Minute))
+			assert.Equal(t, int64(4), c.MustDecrement("counter", 1, time.Minute))
+			var r int64
+			assert.NoError(t, c.Get("counter", &r))
+			assert.Equal(t, int64(4), r)
+			c.MustSet("text", "text", time.Minute)
+			_, err = c.Increment("text", 1, time.Minute)
+			assert.Error(t, err)
+		},
+	)
+	
+	t.Run(
+		"set nx", func(t *testing.T) {
+			assert.True(t, c.MustSetNX("once", "first", time.Minute))
+			assert.False(t, c.MustSetNX("once", "second", time.Minute))
+			var r string
+			c.MustGet("once", &r)
+			assert.Equal(t, "first", r)
+		},
+	)
+	
+	t.Run(
+		"lock", func(t *testing.T) {
+			l, err := c.Lock("job", time.Minute)
+			assert.NoError(t, err)
+			_, err = c.Lock("job", time.Minute)
+			assert.ErrorIs(t, err, ErrorLockNotAcquired)
+			assert.NoError(t, l.Release())
+			assert.ErrorIs(t, l.Release(), ErrorLockNotHeld)
+			l, err = c.Lock("job", time.Minute)
+			assert.NoError(t, err)
+			l.MustRelease()
+		},
+	)
+	
+	t.Run(
+		"codecs", func(t *testing.T) {
+			type entry struct {
+				Name      string
+				CreatedAt time.Time
+				Tags      []string
+			}
+			value := entry{Name: "test", CreatedAt: time.Now().UTC().Truncate(time.Second), Tags: []string{"a", "b"}}
+			mem := memory.New(t.TempDir())
+			for _, codec := range []Codec{JsonCodec, GobCodec, MsgpackCodec} {
+				for _, compression := range []Compression{GzipCompression, ZstdCompression} {
+					cc := New(context.Background(), mem, nil, Serialize(codec), Compress(compression, 1))
+					assert.NoError(t, cc.Set("codec", value, time.Minute))
+					var r entry
+					assert.NoError(t, cc.Get("codec", &r))
+					assert.Equal(t, value.Tags, r.Tags)
+					assert.True(t, value.CreatedAt.Equal(r.CreatedAt))
+					var fallback entry
+					assert.NoError(t, New(context.Background(), mem, nil).Get("codec", &fallback))
+					assert.Equal(t, value.Name, fallback.Name)
+				}
+			}
+		},
+	)
+	
+	t.Run(
+		"read plain json with binary codec", func(t *testing.T) {
+			mem := memory.New(t.TempDir())
+			assert.NoError(t, mem.Set("legacy", `{"value":1}`, time.Minute))
+			var r map[string]int
+			assert.NoError(t, New(context.Background(), mem, nil, Serialize(GobCodec)).Get("legacy", &r))
+			assert.Equal(t, 1, r["value"])
+		},
+	)
+	
+	t.Run(
+		"bulk", func(t *testing.T) {
+			dir := t.TempDir()
+			mem := memory.New(dir)
+			bc := New(context.Background(), mem, nil)
+			assert.NoError(
+				t, bc.SetMany(
+					map[string]any{
+						"translation:cs:title": "Nadpis",
+						"translation:en:title": "Title",
+						"translation:en:body":  "Body",
+						"other":                "other",
+					},
+					time.Minute,
+				),
+			)
+			var r map[string]string
+			assert.NoError(t, bc.GetMany([]string{"translation:cs:title", "translation:en:title", "missing"}, &r))
+			assert.Equal(t, map[string]string{"translation:cs:title": "Nadpis", "translation:en:title": "Title"}, r)
+			assert.Equal(t, []string{"translation:en:body", "translation:en:title"}, bc.MustKeys("translation:en:*"))
+			assert.Equal(t, []string{"translation:cs:title"}, bc.MustKeys("translation:?s:*"))
+			assert.ErrorIs(t, bc.GetMany([]string{"other"}, r), ErrorInvalidManyTarget)
+			assert.NoError(t, bc.DestroyPrefix("translation:"))
+			assert.Equal(t, []string{"other"}, bc.MustKeys("*"))
+			entries, err := os.ReadDir(dir + "/.arcanum/memory")
+			assert.NoError(t, err)
+			assert.Equal(t, 1, len(entries))
+			assert.NoError(t, bc.DestroyMany("other"))
+			assert.Equal(t, []string{}, bc.MustKeys("*"))
+		},
+	)
+	
+	t.Run(
+		"namespace", func(t *testing.T) {
+			nc := New(context.Background(), memory.New(t.TempDir()), nil)
+			auth := nc.Namespace("auth", time.Minute)
+			csrf := nc.Namespace("csrf")
+			auth.MustSet("token", "auth", 0, "user:1")
+			csrf.MustSet("token", "csrf", time.Minute, "user:1")
+			var r string
+			auth.MustGet("token", &r)
+			assert.Equal(t, "auth", r)
+			csrf.MustGet("token", &r)
+			assert.Equal(t, "csrf", r)
+			assert.True(t, nc.Exists("auth:token"))
+			assert.Equal(t, []string{"token"}, auth.MustKeys("*"))
+			assert.NoError(t, auth.InvalidateTags("user:1"))
+			assert.False(t, auth.Exists("token"))
+			assert.True(t, csrf.Exists("token"))
+			auth.MustSet("a", "a", 0)
+			auth.Namespace("nested").MustSet("b", "b", time.Minute)
+			assert.True(t, nc.Exists("auth:nested:b"))
+			_, err := auth.Lock("job", time.Minute)
+			assert.NoError(t, err)
+			assert.NoError(t, auth.Flush())
+			assert.Equal(t, []string{"csrf:token"}, nc.MustKeys("*"))
+			assert.ErrorIs(t, nc.Flush(), ErrorMissingNamespace)
+		},
+	)
+	
+	t.Run(
+		"layered local per redis", func(t *testing.T) {
+			first := redis.NewClient(&redis.Options{Addr: "localhost:6379", DB: 10})
+			second := redis.NewClient(&redis.Options{Addr: "localhost:6379", DB: 11})
+			assert.Same(t, getLocal(first), getLocal(first))
+			assert.NotSame(t, getLocal(first), getLocal(second))
+			assert.NoError(t, getLocal(first).Set("key", "first", time.Minute))
+			assert.Equal(t, "", getLocal(second).Get("key"))
+		},
+	)
+}
+
+func TestRedis(t *testing.T) {
+	client := createTestRedisConnection(t)
+	ctx := context.Background()
+	
+	t.Run(
+		"redis", func(t *testing.T) {
+			c := New(ctx, nil, client).Namespace("test-redis")
+			defer c.MustFlush()
+			c.MustSet("a", "a", time.Minute, "group")
+			c.MustSet("b", "b", 0, "group")
+			var r string
+			c.MustGet("a", &r)
+			assert.Equal(t, "a", r)
+			assert.Equal(t, time.Duration(-1), client.PTTL(ctx, "test-redis:b").Val())
+			var many map[string]string
+			c.MustGetMany([]string{"a", "b", "missing"}, &many)
+			assert.Equal(t, map[string]string{"a": "a", "b": "b"}, many)
+			assert.Equal(t, int64(3), c.MustIncrement("counter", 3, time.Minute))
+			c.MustInvalidateTags("group")
+			assert.False(t, c.Exists("a"))
+			assert.False(t, c.Exists("b"))
+			l, err := c.Lock("job", time.Minute)
+			assert.NoError(t, err)
+			_, err = c.Lock("job", time.Minute)
+			assert.ErrorIs(t, err, ErrorLockNotAcquired)
+			l.MustRelease()
+			c.MustSet("tagged", "tagged", time.Minute, "group")
+			_, err = c.Lock("held", time.Minute)
+			assert.NoError(t, err)
+			assert.NoError(t, c.Flush())
+			assert.Equal(t, int64(0), client.Exists(ctx, "cache-tag:test-redis:group", "cache-lock:test-redis:held").Val())
+		},
+	)
+	
+	t.Run(
+		"layered get clamps local expiration", func(t *testing.T) {
+			mem := memory.New(t.TempDir(), memory.Persistence(false))
+			c := New(ctx, mem, client, Layered(time.Minute)).Namespace("test-layered")
+			defer c.MustFlush()
+			assert.NoError(t, client.Set(ctx, "test-layered:short", `"short"`, 100*time.Millisecond).Err())
+			var r string
+			c.MustGet("short", &r)
+			assert.Equal(t, "short", r)
+			assert.True(t, mem.Exists("test-layered:short"))
+			time.Sleep(150 * time.Millisecond)
+			assert.False(t, mem.Exists("test-layered:short"))
+		},
+	)
+	
+	t.Run(
+		"layered get many clamps local expiration", func(t *testing.T) {
+			mem := memory.New(t.TempDir(), memory.Persistence(false))
+			c := New(ctx, mem, client, Layered(time.Minute)).Namespace("test-layered")
+			defer c.MustFlush()
+			assert.NoError(t, client.Set(ctx, "test-layered:short", `"short"`, 100*time.Millisecond).Err())
+			assert.NoError(t, client.Set(ctx, "test-layered:long", `"long"`, 0).Err())
+			var r map[string]string
+			c.MustGetMany([]string{"short", "long"}, &r)
+			assert.Equal(t, map[string]string{"short": "short", "long": "long"}, r)
+			assert.True(t, mem.Exists("test-layered:short"))
+			time.Sleep(150 * time.Millisecond)
+			assert.False(t, mem.Exists("test-layered:short"))
+			assert.True(t, mem.Exists("test-layered:long"))
+			r = nil
+			c.MustGetMany([]string{"short", "long"}, &r)
+			assert.Equal(t, map[string]string{"long": "long"}, r)
+		},
+	)
+}
+
+func createTestRedisConnection(t *testing.T) *redis.Client {
+	client := redis.NewClient(
+		&redis.Options{
+			Addr: "localhost:6379",
+			DB:   10,
+		},
+	)
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Skip(err)
+	}
+	return client
+}
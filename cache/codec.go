@@ -0,0 +1,227 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"strings"
+	
+	"github.com/klauspost/compress/zstd"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type Codec interface {
+	Name() string
+	Marshal(data any) ([]byte, error)
+	Unmarshal(b []byte, data any) error
+}
+
+type Compression interface {
+	Name() string
+	Compress(b []byte) ([]byte, error)
+	Decompress(b []byte) ([]byte, error)
+}
+
+type jsonCodec struct{}
+
+type gobCodec struct{}
+
+type msgpackCodec struct{}
+
+type noCompression struct{}
+
+type gzipCompression struct{}
+
+type zstdCompression struct{}
+
+const (
+	encodedPrefix    = "$arc:"
+	encodedDelimiter = ":"
+)
+
+const (
+	DefaultCompressionThreshold = 1024
+)
+
+var (
+	JsonCodec       Codec       = jsonCodec{}
+	GobCodec        Codec       = gobCodec{}
+	MsgpackCodec    Codec       = msgpackCodec{}
+	NoCompression   Compression = noCompression{}
+	GzipCompression Compression = gzipCompression{}
+	ZstdCompression Compression = zstdCompression{}
+)
+
+var (
+	codecs       = []Codec{JsonCodec, GobCodec, MsgpackCodec}
+	compressions = []Compression{NoCompression, GzipCompression, ZstdCompression}
+)
+
+func (c cache) encode(data any) (string, error) {
+	b, err := c.codec.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	compression := NoCompression
+	if c.compression != nil && len(b) >= c.compressionThreshold {
+		compression = c.compression
+	}
+	if c.codec.Name() == JsonCodec.Name() && compression == NoCompression {
+		return string(b), nil
+	}
+	b, err = compression.Compress(b)
+	if err != nil {
+		return "", err
+	}
+	return encodedPrefix + c.codec.Name() + encodedDelimiter + compression.Name() + encodedDelimiter +
+		base64.StdEncoding.EncodeToString(b), nil
+}
+
+func (c cache) decode(value string, data any) error {
+	if !strings.HasPrefix(value, encodedPrefix) {
+		return json.Unmarshal([]byte(value), data)
+	}
+	parts := strings.SplitN(strings.TrimPrefix(value, encodedPrefix), encodedDelimiter, 3)
+	if len(parts) != 3 {
+		return ErrorInvalidEncodedValue
+	}
+	codec, compression := c.findCodec(parts[0]), c.findCompression(parts[1])
+	if codec == nil || compression == nil {
+		return ErrorInvalidEncodedValue
+	}
+	b, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	b, err = compression.Decompress(b)
+	if err != nil {
+		return err
+	}
+	return codec.Unmarshal(b, data)
+}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func (jsonCodec) Marshal(data any) ([]byte, error) {
+	return json.Marshal(data)
+}
+
+func (jsonCodec) Unmarshal(b []byte, data any) error {
+	return json.Unmarshal(b, data)
+}
+
+func (gobCodec) Name() string {
+	return "gob"
+}
+
+func (gobCodec) Marshal(data any) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(data); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(b []byte, data any) error {
+	return gob.NewDecoder(bytes.NewReader(b)).Decode(data)
+}
+
+func (msgpackCodec) Name() string {
+	return "msgpack"
+}
+
+func (msgpackCodec) Marshal(data any) ([]byte, error) {
+	return msgpack.Marshal(data)
+}
+
+func (msgpackCodec) Unmarshal(b []byte, data any) error {
+	return msgpack.Unmarshal(b, data)
+}
+
+func (noCompression) Name() string {
+	return "none"
+}
+
+func (noCompression) Compress(b []byte) ([]byte, error) {
+	return b, nil
+}
+
+func (noCompression) Decompress(b []byte) ([]byte, error) {
+	return b, nil
+}
+
+func (gzipCompression) Name() string {
+	return "gzip"
+}
+
+func (gzipCompression) Compress(b []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	w := gzip.NewWriter(&buffer)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (gzipCompression) Decompress(b []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (zstdCompression) Name() string {
+	return "zstd"
+}
+
+func (zstdCompression) Compress(b []byte) ([]byte, error) {
+	w, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer w.Close()
+	return w.EncodeAll(b, nil), nil
+}
+
+func (zstdCompression) Decompress(b []byte) ([]byte, error) {
+	r, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return r.DecodeAll(b, nil)
+}
+
+func (c cache) findCodec(name string) Codec {
+	if c.codec != nil && c.codec.Name() == name {
+		return c.codec
+	}
+	for _, codec := range codecs {
+		if codec.Name() == name {
+			return codec
+		}
+	}
+	return nil
+}
+
+func (c cache) findCompression(name string) Compression {
+	if c.compression != nil && c.compression.Name() == name {
+		return c.compression
+	}
+	for _, compression := range compressions {
+		if compression.Name() == name {
+			return compression
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package cache
+
+import "time"
+
+type Config interface{}
+
+type config struct {
+	name  string
+	value any
+}
+
+type compressionConfig struct {
+	compression Compression
+	threshold   int
+}
+
+const (
+	configCodec       = "codec"
+	configCompression = "compression"
+	configLayered     = "layered"
+)
+
+func Serialize(codec Codec) Config {
+	return &config{
+		name:  configCodec,
+		value: codec,
+	}
+}
+
+func Compress(compression Compression, threshold ...int) Config {
+	value := compressionConfig{
+		compression: compression,
+		threshold:   DefaultCompressionThreshold,
+	}
+	if len(threshold) > 0 && threshold[0] > 0 {
+		value.threshold = threshold[0]
+	}
+	return &config{
+		name:  configCompression,
+		value: value,
+	}
+}
+
+func Layered(expiration time.Duration) Config {
+	return &config{
+		name:  configLayered,
+		value: expiration,
+	}
+}
@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+	
+	"github.com/dchest/uniuri"
+	"github.com/go-redis/redis/v8"
+	
+	"github.com/daarlabs/arcanum/cache/memory"
+)
+
+type invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys"`
+}
+
+type subscription struct {
+	redis  *redis.Client
+	memory *memory.Client
+}
+
+const (
+	invalidationChannel = "arcanum:cache:invalidate"
+	redisMissingTtl     = time.Duration(-2)
+)
+
+var (
+	instance      = uniuri.New()
+	subscriptions = sync.Map{}
+	locals        = sync.Map{}
+)
+
+func getLocal(redis *redis.Client) *memory.Client {
+	if local, ok := locals.Load(redis); ok {
+		return local.(*memory.Client)
+	}
+	local, _ := locals.LoadOrStore(redis, memory.New("", memory.Persistence(false)))
+	return local.(*memory.Client)
+}
+
+func (c cache) getLayeredValue(key string) (string, error) {
+	if value := c.memory.Get(key); len(value) > 0 {
+		return value, nil
+	}
+	value, err := c.redis.Get(c.ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	ttl := c.redis.PTTL(c.ctx, key).Val()
+	if ttl == redisMissingTtl {
+		return value, nil
+	}
+	return value, c.memory.Set(key, value, c.localExpiration(ttl))
+}
+
+func (c cache) getRedisTtls(keys ...string) (map[string]time.Duration, error) {
+	result := make(map[string]time.Duration, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+	cmds := make(map[string]*redis.DurationCmd, len(keys))
+	if _, err := c.redis.Pipelined(
+		c.ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				cmds[key] = pipe.PTTL(c.ctx, key)
+			}
+			return nil
+		},
+	); err != nil {
+		return result, err
+	}
+	for key, cmd := range cmds {
+		result[key] = cmd.Val()
+	}
+	return result, nil
+}
+
+func (c cache) localExpiration(ttl time.Duration) time.Duration {
+	if ttl > 0 && ttl < c.local {
+		return ttl
+	}
+	return c.local
+}
+
+func (c cache) setLayeredValue(key string, value string, expiration time.Duration, tags ...string) error {
+	if err := c.setRedisValue(key, value, expiration, tags...); err != nil {
+		return err
+	}
+	if err := c.publishInvalidation(key); err != nil {
+		return err
+	}
+	local := c.local
+	if expiration > 0 && expiration < local {
+		local = expiration
+	}
+	return c.memory.Set(key, value, local)
+}
+
+func (c cache) invalidateLocal(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	for _, key := range keys {
+		if err := c.memory.Destroy(key); err != nil {
+			return err
+		}
+	}
+	return c.publishInvalidation(keys...)
+}
+
+func (c cache) publishInvalidation(keys ...string) error {
+	b, err := json.Marshal(invalidation{Origin: instance, Keys: keys})
+	if err != nil {
+		return err
+	}
+	return c.redis.Publish(c.ctx, invalidationChannel, string(b)).Err()
+}
+
+func (c cache) subscribe() {
+	s := subscription{redis: c.redis, memory: c.memory}
+	if _, loaded := subscriptions.LoadOrStore(s, true); loaded {
+		return
+	}
+	go s.listen()
+}
+
+func (s subscription) listen() {
+	pubsub := s.redis.Subscribe(context.Background(), invalidationChannel)
+	defer pubsub.Close()
+	for msg := range pubsub.Channel() {
+		var i invalidation
+		if err := json.Unmarshal([]byte(msg.Payload), &i); err != nil {
+			log.Println(err)
+			continue
+		}
+		if i.Origin == instance {
+			continue
+		}
+		for _, key := range i.Keys {
+			if err := s.memory.Destroy(key); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"time"
+	
+	"github.com/dchest/uniuri"
+	"github.com/go-redis/redis/v8"
+)
+
+type Lock interface {
+	Key() string
+	Release() error
+	
+	MustRelease()
+}
+
+type lock struct {
+	cache    cache
+	key      string
+	cacheKey string
+	token    string
+}
+
+var (
+	redisReleaseScript = redis.NewScript(
+		`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`,
+	)
+)
+
+func (c cache) Lock(key string, expiration time.Duration) (Lock, error) {
+	if c.isNil() {
+		return nil, ErrorAdapterInstanceNotExist
+	}
+	return c.lock(createLockCacheKey(c.key(key)), key, expiration)
+}
+
+func (c cache) lock(cacheKey, key string, expiration time.Duration) (*lock, error) {
+	token := uniuri.New()
+	ok, err := c.setNXValue(cacheKey, token, expiration)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrorLockNotAcquired
+	}
+	return &lock{
+		cache:    c,
+		key:      key,
+		cacheKey: cacheKey,
+		token:    token,
+	}, nil
+}
+
+func (l *lock) Key() string {
+	return l.key
+}
+
+func (l *lock) Release() error {
+	var released bool
+	switch l.cache.adapter {
+	case AdapterMemory:
+		ok, err := l.cache.memory.CompareAndDestroy(l.cacheKey, l.token)
+		if err != nil {
+			return err
+		}
+		released = ok
+	case AdapterRedis, AdapterLayered:
+		n, err := redisReleaseScript.Run(
+			l.cache.ctx, l.cache.redis, []string{l.cacheKey}, l.token,
+		).Int()
+		if err != nil {
+			return err
+		}
+		released = n > 0
+	}
+	if !released {
+		return ErrorLockNotHeld
+	}
+	return nil
+}
+
+func (l *lock) MustRelease() {
+	if err := l.Release(); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,39 @@
+package memory
+
+type Config interface{}
+
+type config struct {
+	name  string
+	value any
+}
+
+const (
+	configMaxEntries  = "maxEntries"
+	configMaxBytes    = "maxBytes"
+	configPersistence = "persistence"
+)
+
+func MaxEntries(entries int) Config {
+	return &config{
+		name:  configMaxEntries,
+		value: entries,
+	}
+}
+
+func MaxBytes(bytes int64) Config {
+	return &config{
+		name:  configMaxBytes,
+		value: bytes,
+	}
+}
+
+func Persistence(enabled ...bool) Config {
+	value := true
+	if len(enabled) > 0 {
+		value = enabled[0]
+	}
+	return &config{
+		name:  configPersistence,
+		value: value,
+	}
+}
@@ -0,0 +1,7 @@
+package memory
+
+import "errors"
+
+var (
+	ErrorNotInteger = errors.New("value is not an integer")
+)
@@ -1,12 +1,16 @@
 package memory
 
 import (
+	"container/list"
 	"encoding/json"
 	"fmt"
 	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -14,13 +18,32 @@ import (
 
 type Client struct {
 	sync.RWMutex
-	data map[string]data
-	dir  string
+	data        map[string]data
+	tags        map[string]map[string]struct{}
+	recency     *list.List
+	elements    map[string]*list.Element
+	dir         string
+	persistence bool
+	maxEntries  int
+	maxBytes    int64
+	bytes       int64
+	hits        uint64
+	misses      uint64
+	evictions   uint64
+}
+
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+	Bytes     int64  `json:"bytes"`
 }
 
 type data struct {
 	Value      string    `json:"value"`
 	Expiration time.Time `json:"expiration"`
+	Tags       []string  `json:"tags,omitempty"`
 }
 
 const (
@@ -28,10 +51,28 @@ const (
 	watchInterval = time.Second
 )
 
-func New(dir string) *Client {
+func New(dir string, configs ...Config) *Client {
 	m := &Client{
-		data: make(map[string]data),
-		dir:  getDir(dir),
+		data:        make(map[string]data),
+		tags:        make(map[string]map[string]struct{}),
+		recency:     list.New(),
+		elements:    make(map[string]*list.Element),
+		dir:         getDir(dir),
+		persistence: true,
+	}
+	for _, item := range configs {
+		c, ok := item.(*config)
+		if !ok {
+			continue
+		}
+		switch c.name {
+		case configMaxEntries:
+			m.maxEntries = c.value.(int)
+		case configMaxBytes:
+			m.maxBytes = c.value.(int64)
+		case configPersistence:
+			m.persistence = c.value.(bool)
+		}
 	}
 	go m.load()
 	go m.watch()
@@ -40,52 +81,311 @@ func New(dir string) *Client {
 
 func (m *Client) Get(key string) string {
 	m.Lock()
+	defer m.Unlock()
 	d, ok := m.data[key]
-	m.Unlock()
+	if ok && d.expired(time.Now()) {
+		ok = false
+		if err := m.destroy(key); err != nil {
+			log.Println(err)
+		}
+	}
 	if !ok {
+		m.misses++
 		return ""
 	}
+	m.hits++
+	m.touch(key)
 	return d.Value
 }
 
-func (m *Client) Set(key string, value string, expiration time.Duration) error {
+func (m *Client) Set(key string, value string, expiration time.Duration, tags ...string) error {
 	d := data{
 		Value:      value,
-		Expiration: time.Now().Add(expiration),
+		Expiration: expiresAt(expiration),
+		Tags:       tags,
 	}
 	m.Lock()
-	m.data[key] = d
-	m.Unlock()
+	defer m.Unlock()
+	m.store(key, d)
 	if err := m.setTempFile(key, d); err != nil {
 		return err
 	}
-	return nil
+	return m.evict(key)
 }
 
 func (m *Client) Exists(key string) bool {
-	_, ok := m.data[key]
-	return ok
+	m.RLock()
+	d, ok := m.data[key]
+	m.RUnlock()
+	return ok && !d.expired(time.Now())
+}
+
+func (m *Client) SetNX(key string, value string, expiration time.Duration) (bool, error) {
+	m.Lock()
+	defer m.Unlock()
+	if d, ok := m.data[key]; ok && !d.expired(time.Now()) {
+		return false, nil
+	}
+	d := data{
+		Value:      value,
+		Expiration: expiresAt(expiration),
+	}
+	m.store(key, d)
+	if err := m.setTempFile(key, d); err != nil {
+		return false, err
+	}
+	return true, m.evict(key)
+}
+
+func (m *Client) Increment(key string, value int64, expiration time.Duration) (int64, error) {
+	m.Lock()
+	defer m.Unlock()
+	d, ok := m.data[key]
+	if !ok || d.expired(time.Now()) {
+		d = data{
+			Value:      "0",
+			Expiration: expiresAt(expiration),
+		}
+	}
+	n, err := strconv.ParseInt(d.Value, 10, 64)
+	if err != nil {
+		return 0, ErrorNotInteger
+	}
+	n += value
+	d.Value = strconv.FormatInt(n, 10)
+	m.store(key, d)
+	if err := m.setTempFile(key, d); err != nil {
+		return n, err
+	}
+	return n, m.evict(key)
+}
+
+func (m *Client) CompareAndDestroy(key string, value string) (bool, error) {
+	m.Lock()
+	defer m.Unlock()
+	d, ok := m.data[key]
+	if !ok || d.Value != value {
+		return false, nil
+	}
+	return true, m.destroy(key)
+}
+
+func (m *Client) GetMany(keys ...string) map[string]string {
+	result := make(map[string]string)
+	for _, key := range keys {
+		if value := m.Get(key); len(value) > 0 {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+func (m *Client) SetMany(values map[string]string, expiration time.Duration, tags ...string) error {
+	for key, value := range values {
+		if err := m.Set(key, value, expiration, tags...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Client) DestroyMany(keys ...string) error {
+	m.Lock()
+	defer m.Unlock()
+	for _, key := range keys {
+		if err := m.destroy(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Client) DestroyPrefix(prefix string) ([]string, error) {
+	m.Lock()
+	defer m.Unlock()
+	keys := make([]string, 0)
+	for key := range m.data {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if err := m.destroy(key); err != nil {
+			return keys, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (m *Client) Keys(pattern string) ([]string, error) {
+	matcher, err := createPatternMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+	t := time.Now()
+	m.RLock()
+	defer m.RUnlock()
+	keys := make([]string, 0)
+	for key, d := range m.data {
+		if d.expired(t) || !matcher.MatchString(key) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+func (m *Client) Stats() Stats {
+	m.RLock()
+	defer m.RUnlock()
+	return Stats{
+		Hits:      m.hits,
+		Misses:    m.misses,
+		Evictions: m.evictions,
+		Entries:   len(m.data),
+		Bytes:     m.bytes,
+	}
 }
 
 func (m *Client) Destroy(key string) error {
+	m.Lock()
+	defer m.Unlock()
+	return m.destroy(key)
+}
+
+func (m *Client) InvalidateTags(tags ...string) error {
+	m.Lock()
+	defer m.Unlock()
+	for _, t := range tags {
+		for key := range m.tags[t] {
+			if err := m.destroy(key); err != nil {
+				return err
+			}
+		}
+		delete(m.tags, t)
+	}
+	return nil
+}
+
+func (m *Client) store(key string, d data) {
+	if existing, ok := m.data[key]; ok {
+		m.bytes -= existing.size(key)
+	}
+	m.untag(key)
+	m.data[key] = d
+	m.bytes += d.size(key)
+	m.tag(key, d.Tags...)
+	m.touch(key)
+}
+
+func (m *Client) destroy(key string) error {
+	if d, ok := m.data[key]; ok {
+		m.bytes -= d.size(key)
+	}
+	m.untag(key)
 	delete(m.data, key)
+	if e, ok := m.elements[key]; ok {
+		m.recency.Remove(e)
+		delete(m.elements, key)
+	}
 	if err := m.deleteTempFile(key); err != nil {
 		return err
 	}
 	return nil
 }
 
+func (m *Client) touch(key string) {
+	if m.recency == nil {
+		m.recency = list.New()
+		m.elements = make(map[string]*list.Element)
+	}
+	if e, ok := m.elements[key]; ok {
+		m.recency.MoveToFront(e)
+		return
+	}
+	m.elements[key] = m.recency.PushFront(key)
+}
+
+func (m *Client) evict(keep string) error {
+	for m.overflows() {
+		e := m.recency.Back()
+		if e == nil {
+			return nil
+		}
+		key := e.Value.(string)
+		if key == keep {
+			if m.recency.Len() == 1 {
+				return nil
+			}
+			m.recency.MoveToFront(e)
+			continue
+		}
+		if err := m.destroy(key); err != nil {
+			return err
+		}
+		m.evictions++
+	}
+	return nil
+}
+
+func (m *Client) overflows() bool {
+	if m.maxEntries > 0 && len(m.data) > m.maxEntries {
+		return true
+	}
+	if m.maxBytes > 0 && m.bytes > m.maxBytes {
+		return true
+	}
+	return false
+}
+
+func (m *Client) tag(key string, tags ...string) {
+	if m.tags == nil {
+		m.tags = make(map[string]map[string]struct{})
+	}
+	for _, t := range tags {
+		if _, ok := m.tags[t]; !ok {
+			m.tags[t] = make(map[string]struct{})
+		}
+		m.tags[t][key] = struct{}{}
+	}
+}
+
+func (m *Client) untag(key string) {
+	d, ok := m.data[key]
+	if !ok {
+		return
+	}
+	for _, t := range d.Tags {
+		delete(m.tags[t], key)
+		if len(m.tags[t]) == 0 {
+			delete(m.tags, t)
+		}
+	}
+}
+
 func (m *Client) load() {
+	if !m.persistence {
+		return
+	}
 	if _, err := os.Stat(m.dir); os.IsNotExist(err) {
 		return
 	}
 	if err := filepath.Walk(
 		m.dir, func(path string, info fs.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
 			if info.IsDir() || !strings.HasSuffix(info.Name(), jsonSuffix) {
 				return nil
 			}
 			key := strings.TrimSuffix(info.Name(), jsonSuffix)
 			fbts, err := os.ReadFile(path)
+			if os.IsNotExist(err) {
+				return nil
+			}
 			if err != nil {
 				return err
 			}
@@ -96,8 +396,13 @@ func (m *Client) load() {
 			if err := json.Unmarshal(fbts, &d); err != nil {
 				return err
 			}
-			m.data[key] = d
-			return nil
+			m.Lock()
+			defer m.Unlock()
+			if d.expired(time.Now()) {
+				return m.deleteTempFile(key)
+			}
+			m.store(key, d)
+			return m.evict(key)
 		},
 	); err != nil {
 		log.Fatalln(err)
@@ -111,30 +416,24 @@ func (m *Client) watch() {
 		select {
 		case <-ticker.C:
 			t := time.Now()
-			expired := make([]string, 0)
-			m.RLock()
+			m.Lock()
 			for key, d := range m.data {
-				if t.Before(d.Expiration) {
+				if !d.expired(t) {
 					continue
 				}
-				expired = append(expired, key)
-			}
-			m.RUnlock()
-			if len(expired) > 0 {
-				m.Lock()
-				for _, key := range expired {
-					if err := m.Destroy(key); err != nil {
-						log.Fatalln(err)
-					}
+				if err := m.destroy(key); err != nil {
+					log.Fatalln(err)
 				}
-				expired = nil
-				m.Unlock()
 			}
+			m.Unlock()
 		}
 	}
 }
 
 func (m *Client) setTempFile(key string, d data) error {
+	if !m.persistence {
+		return nil
+	}
 	if _, err := os.Stat(m.dir); os.IsNotExist(err) {
 		if err := os.MkdirAll(m.dir, os.ModePerm); err != nil {
 			return err
@@ -157,6 +456,9 @@ func (m *Client) setTempFile(key string, d data) error {
 }
 
 func (m *Client) deleteTempFile(key string) error {
+	if !m.persistence {
+		return nil
+	}
 	if _, err := os.Stat(m.dir); os.IsNotExist(err) {
 		return nil
 	}
@@ -169,6 +471,60 @@ func (m *Client) deleteTempFile(key string) error {
 	return nil
 }
 
+func (d data) expired(t time.Time) bool {
+	if d.Expiration.IsZero() {
+		return false
+	}
+	return !t.Before(d.Expiration)
+}
+
+func (d data) size(key string) int64 {
+	return int64(len(key) + len(d.Value))
+}
+
+func expiresAt(expiration time.Duration) time.Time {
+	if expiration == 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(expiration)
+}
+
+func createPatternMatcher(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	escaped := false
+	class := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case class:
+			if r == ']' {
+				class = false
+			}
+			if r == ']' || r == '^' || r == '-' {
+				b.WriteRune(r)
+				continue
+			}
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		case r == '*':
+			b.WriteString(".*")
+		case r == '?':
+			b.WriteString(".")
+		case r == '[':
+			class = true
+			b.WriteRune(r)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
 func getDir(tmpDir string) string {
 	if strings.HasSuffix(tmpDir, "/") {
 		tmpDir = strings.TrimSuffix(tmpDir, "/")
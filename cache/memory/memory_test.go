@@ -1,6 +1,7 @@
 package memory
 
 import (
+	"os"
 	"testing"
 	"time"
 
@@ -9,8 +10,9 @@ import (
 
 func TestMemory(t *testing.T) {
 	m := &Client{
-		data: make(map[string]data),
-		dir:  getDir(t.TempDir()),
+		data:        make(map[string]data),
+		dir:         getDir(t.TempDir()),
+		persistence: true,
 	}
 
 	t.Run(
@@ -39,4 +41,79 @@ func TestMemory(t *testing.T) {
 			assert.Equal(t, true, m.Exists("test"))
 		},
 	)
+	
+	t.Run(
+		"invalidate tags", func(t *testing.T) {
+			assert.Nil(t, m.Set("user:1:profile", "profile", time.Minute, "user:1"))
+			assert.Nil(t, m.Set("user:1:orders", "orders", time.Minute, "user:1", "orders"))
+			assert.Nil(t, m.Set("user:2:orders", "orders", time.Minute, "user:2", "orders"))
+			assert.Nil(t, m.InvalidateTags("user:1"))
+			assert.False(t, m.Exists("user:1:profile"))
+			assert.False(t, m.Exists("user:1:orders"))
+			assert.True(t, m.Exists("user:2:orders"))
+			assert.Nil(t, m.InvalidateTags("orders"))
+			assert.False(t, m.Exists("user:2:orders"))
+			assert.Equal(t, 0, len(m.tags))
+		},
+	)
+	
+	t.Run(
+		"evict least recently used", func(t *testing.T) {
+			b := New(t.TempDir(), MaxEntries(2), Persistence(false))
+			assert.Nil(t, b.Set("a", "a", time.Minute))
+			assert.Nil(t, b.Set("b", "b", time.Minute))
+			assert.Equal(t, "a", b.Get("a"))
+			assert.Nil(t, b.Set("c", "c", time.Minute))
+			assert.True(t, b.Exists("a"))
+			assert.False(t, b.Exists("b"))
+			assert.True(t, b.Exists("c"))
+			assert.Equal(t, "", b.Get("b"))
+			stats := b.Stats()
+			assert.Equal(t, uint64(1), stats.Hits)
+			assert.Equal(t, uint64(1), stats.Misses)
+			assert.Equal(t, uint64(1), stats.Evictions)
+			assert.Equal(t, 2, stats.Entries)
+		},
+	)
+	
+	t.Run(
+		"evict over max bytes", func(t *testing.T) {
+			dir := t.TempDir()
+			b := New(dir, MaxBytes(10))
+			assert.Nil(t, b.Set("a", "1234", time.Minute))
+			assert.Nil(t, b.Set("b", "1234", time.Minute))
+			assert.Nil(t, b.Set("c", "1234", time.Minute))
+			assert.False(t, b.Exists("a"))
+			assert.True(t, b.Exists("c"))
+			assert.Equal(t, int64(10), b.Stats().Bytes)
+			_, err := os.Stat(getDir(dir) + "/a" + jsonSuffix)
+			assert.True(t, os.IsNotExist(err))
+		},
+	)
+	
+	t.Run(
+		"expired", func(t *testing.T) {
+			assert.Nil(t, m.Set("expired", "expired", -time.Second))
+			assert.False(t, m.Exists("expired"))
+			assert.Equal(t, "", m.Get("expired"))
+		},
+	)
+	
+	t.Run(
+		"no expiration", func(t *testing.T) {
+			assert.Nil(t, m.Set("persistent", "persistent", 0))
+			assert.True(t, m.Exists("persistent"))
+			assert.Equal(t, "persistent", m.Get("persistent"))
+			n, err := m.Increment("counter", 1, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, int64(1), n)
+			assert.True(t, m.Exists("counter"))
+			ok, err := m.SetNX("lock", "1", 0)
+			assert.Nil(t, err)
+			assert.True(t, ok)
+			ok, err = m.SetNX("lock", "1", 0)
+			assert.Nil(t, err)
+			assert.False(t, ok)
+		},
+	)
 }
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	namespaceDelimiter = ":"
+)
+
+func (c cache) Namespace(name string, expiration ...time.Duration) Client {
+	c.namespace = c.key(name) + namespaceDelimiter
+	if len(expiration) > 0 {
+		c.expiration = expiration[0]
+	}
+	return &c
+}
+
+func (c cache) Flush() error {
+	if len(c.namespace) == 0 {
+		return ErrorMissingNamespace
+	}
+	prefixes := []string{c.namespace, createTagCacheKey(c.namespace), createLockCacheKey(c.namespace)}
+	for _, prefix := range prefixes {
+		if err := c.destroyPrefix(prefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c cache) MustFlush() {
+	if err := c.Flush(); err != nil {
+		panic(err)
+	}
+}
+
+func (c cache) key(key string) string {
+	return c.namespace + key
+}
+
+func (c cache) keys(keys ...string) []string {
+	if len(c.namespace) == 0 {
+		return keys
+	}
+	result := make([]string, len(keys))
+	for i, key := range keys {
+		result[i] = c.key(key)
+	}
+	return result
+}
+
+func (c cache) trimKey(key string) string {
+	return strings.TrimPrefix(key, c.namespace)
+}
+
+func (c cache) ttl(expiration time.Duration) time.Duration {
+	if expiration == 0 && c.expiration > 0 {
+		return c.expiration
+	}
+	return expiration
+}
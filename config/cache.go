@@ -1,12 +1,19 @@
 package config
 
 import (
+	"time"
+	
 	"github.com/go-redis/redis/v8"
 	
+	"github.com/daarlabs/arcanum/cache"
 	"github.com/daarlabs/arcanum/cache/memory"
 )
 
 type Cache struct {
-	Memory *memory.Client
-	Redis  *redis.Client
+	Memory               *memory.Client
+	Redis                *redis.Client
+	Layered              time.Duration
+	Codec                cache.Codec
+	Compression          cache.Compression
+	CompressionThreshold int
 }
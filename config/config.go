@@ -11,6 +11,7 @@ import (
 type Config struct {
 	App          App
 	Cache        Cache
+	Cookie       Cookie
 	Database     map[string]*quirk.DB
 	Export       Export
 	Form         form.Config
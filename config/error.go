@@ -0,0 +1,9 @@
+package config
+
+import "errors"
+
+var (
+	ErrorUnsupportedFormat = errors.New("unsupported config file format")
+	ErrorMissingField      = errors.New("missing required config field")
+	ErrorInvalidField      = errors.New("invalid config field")
+)
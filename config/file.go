@@ -0,0 +1,164 @@
+package config
+
+import (
+	"time"
+	
+	"github.com/daarlabs/arcanum/auth"
+	"github.com/daarlabs/arcanum/mailer"
+)
+
+type file struct {
+	App          fileApp                 `json:"app" toml:"app" yaml:"app"`
+	Cache        fileCache               `json:"cache" toml:"cache" yaml:"cache"`
+	Cookie       fileCookie              `json:"cookie" toml:"cookie" yaml:"cookie"`
+	Database     map[string]fileDatabase `json:"database" toml:"database" yaml:"database"`
+	Export       fileExport              `json:"export" toml:"export" yaml:"export"`
+	Filesystem   fileFilesystem          `json:"filesystem" toml:"filesystem" yaml:"filesystem"`
+	Form         fileForm                `json:"form" toml:"form" yaml:"form"`
+	Localization fileLocalization        `json:"localization" toml:"localization" yaml:"localization"`
+	Parser       fileParser              `json:"parser" toml:"parser" yaml:"parser"`
+	Router       fileRouter              `json:"router" toml:"router" yaml:"router"`
+	Security     fileSecurity            `json:"security" toml:"security" yaml:"security"`
+	Smtp         mailer.Config           `json:"smtp" toml:"smtp" yaml:"smtp"`
+}
+
+type fileApp struct {
+	Plugin         bool   `json:"plugin" toml:"plugin" yaml:"plugin"`
+	Name           string `json:"name" toml:"name" yaml:"name"`
+	PublicUrlPath  string `json:"publicUrlPath" toml:"publicUrlPath" yaml:"publicUrlPath"`
+	PublicLocalDir string `json:"publicLocalDir" toml:"publicLocalDir" yaml:"publicLocalDir"`
+}
+
+type fileCache struct {
+	Memory               *fileMemory `json:"memory" toml:"memory" yaml:"memory"`
+	Redis                *fileRedis  `json:"redis" toml:"redis" yaml:"redis"`
+	Layered              duration    `json:"layered" toml:"layered" yaml:"layered"`
+	Codec                string      `json:"codec" toml:"codec" yaml:"codec"`
+	Compression          string      `json:"compression" toml:"compression" yaml:"compression"`
+	CompressionThreshold int         `json:"compressionThreshold" toml:"compressionThreshold" yaml:"compressionThreshold"`
+}
+
+type fileMemory struct {
+	Dir         string `json:"dir" toml:"dir" yaml:"dir"`
+	MaxEntries  int    `json:"maxEntries" toml:"maxEntries" yaml:"maxEntries"`
+	MaxBytes    int64  `json:"maxBytes" toml:"maxBytes" yaml:"maxBytes"`
+	Persistence *bool  `json:"persistence" toml:"persistence" yaml:"persistence"`
+}
+
+type fileRedis struct {
+	Addr     string `json:"addr" toml:"addr" yaml:"addr"`
+	Username string `json:"username" toml:"username" yaml:"username"`
+	Password string `json:"password" toml:"password" yaml:"password"`
+	Db       int    `json:"db" toml:"db" yaml:"db"`
+}
+
+type fileCookie struct {
+	Domain      string   `json:"domain" toml:"domain" yaml:"domain"`
+	HttpOnly    bool     `json:"httpOnly" toml:"httpOnly" yaml:"httpOnly"`
+	SameSite    string   `json:"sameSite" toml:"sameSite" yaml:"sameSite"`
+	Secure      bool     `json:"secure" toml:"secure" yaml:"secure"`
+	MaxAge      bool     `json:"maxAge" toml:"maxAge" yaml:"maxAge"`
+	Partitioned bool     `json:"partitioned" toml:"partitioned" yaml:"partitioned"`
+	Prefix      string   `json:"prefix" toml:"prefix" yaml:"prefix"`
+	Sign        []string `json:"sign" toml:"sign" yaml:"sign"`
+	Encrypt     []string `json:"encrypt" toml:"encrypt" yaml:"encrypt"`
+}
+
+type fileDatabase struct {
+	Driver   string `json:"driver" toml:"driver" yaml:"driver"`
+	Host     string `json:"host" toml:"host" yaml:"host"`
+	Port     int    `json:"port" toml:"port" yaml:"port"`
+	Dbname   string `json:"dbname" toml:"dbname" yaml:"dbname"`
+	User     string `json:"user" toml:"user" yaml:"user"`
+	Password string `json:"password" toml:"password" yaml:"password"`
+	Ssl      string `json:"ssl" toml:"ssl" yaml:"ssl"`
+	CertPath string `json:"certPath" toml:"certPath" yaml:"certPath"`
+	Log      bool   `json:"log" toml:"log" yaml:"log"`
+}
+
+type fileExport struct {
+	Gotenberg fileGotenberg `json:"gotenberg" toml:"gotenberg" yaml:"gotenberg"`
+}
+
+type fileGotenberg struct {
+	Endpoint string `json:"endpoint" toml:"endpoint" yaml:"endpoint"`
+}
+
+type fileFilesystem struct {
+	Driver string     `json:"driver" toml:"driver" yaml:"driver"`
+	Name   string     `json:"name" toml:"name" yaml:"name"`
+	Dir    string     `json:"dir" toml:"dir" yaml:"dir"`
+	Cloud  *fileCloud `json:"cloud" toml:"cloud" yaml:"cloud"`
+}
+
+type fileCloud struct {
+	Endpoint  string `json:"endpoint" toml:"endpoint" yaml:"endpoint"`
+	AccessKey string `json:"accessKey" toml:"accessKey" yaml:"accessKey"`
+	SecretKey string `json:"secretKey" toml:"secretKey" yaml:"secretKey"`
+	Region    string `json:"region" toml:"region" yaml:"region"`
+	Secure    bool   `json:"secure" toml:"secure" yaml:"secure"`
+}
+
+type fileForm struct {
+	Limit int `json:"limit" toml:"limit" yaml:"limit"`
+}
+
+type fileLocalization struct {
+	Enabled   bool           `json:"enabled" toml:"enabled" yaml:"enabled"`
+	Path      bool           `json:"path" toml:"path" yaml:"path"`
+	Languages []fileLanguage `json:"languages" toml:"languages" yaml:"languages"`
+}
+
+type fileLanguage struct {
+	Main bool   `json:"main" toml:"main" yaml:"main"`
+	Code string `json:"code" toml:"code" yaml:"code"`
+}
+
+type fileParser struct {
+	Limit int64 `json:"limit" toml:"limit" yaml:"limit"`
+}
+
+type fileRouter struct {
+	Prefix  filePrefix `json:"prefix" toml:"prefix" yaml:"prefix"`
+	Recover bool       `json:"recover" toml:"recover" yaml:"recover"`
+}
+
+type filePrefix struct {
+	Name   string `json:"name" toml:"name" yaml:"name"`
+	Proxy  string `json:"proxy" toml:"proxy" yaml:"proxy"`
+	Cookie string `json:"cookie" toml:"cookie" yaml:"cookie"`
+	Path   string `json:"path" toml:"path" yaml:"path"`
+}
+
+type fileSecurity struct {
+	Auth      auth.Config    `json:"auth" toml:"auth" yaml:"auth"`
+	Csrf      *fileCsrf      `json:"csrf" toml:"csrf" yaml:"csrf"`
+	Firewalls []fileFirewall `json:"firewalls" toml:"firewalls" yaml:"firewalls"`
+}
+
+type fileCsrf struct {
+	Enabled    *bool    `json:"enabled" toml:"enabled" yaml:"enabled"`
+	Expiration duration `json:"expiration" toml:"expiration" yaml:"expiration"`
+}
+
+type fileFirewall struct {
+	Enabled  bool     `json:"enabled" toml:"enabled" yaml:"enabled"`
+	Name     string   `json:"name" toml:"name" yaml:"name"`
+	Groups   []string `json:"groups" toml:"groups" yaml:"groups"`
+	Matchers []string `json:"matchers" toml:"matchers" yaml:"matchers"`
+	Paths    []string `json:"paths" toml:"paths" yaml:"paths"`
+	Redirect string   `json:"redirect" toml:"redirect" yaml:"redirect"`
+	Roles    []string `json:"roles" toml:"roles" yaml:"roles"`
+	Secret   string   `json:"secret" toml:"secret" yaml:"secret"`
+}
+
+type duration time.Duration
+
+func (d *duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
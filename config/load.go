@@ -0,0 +1,364 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	
+	"github.com/BurntSushi/toml"
+	"github.com/go-redis/redis/v8"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"gopkg.in/yaml.v3"
+	
+	"github.com/daarlabs/arcanum/auth"
+	"github.com/daarlabs/arcanum/cache"
+	"github.com/daarlabs/arcanum/cache/memory"
+	"github.com/daarlabs/arcanum/csrf"
+	"github.com/daarlabs/arcanum/filesystem"
+	"github.com/daarlabs/arcanum/firewall"
+	"github.com/daarlabs/arcanum/quirk"
+)
+
+var (
+	interpolationMatcher = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?}`)
+)
+
+var (
+	sameSites = map[string]http.SameSite{
+		"default": http.SameSiteDefaultMode,
+		"lax":     http.SameSiteLaxMode,
+		"strict":  http.SameSiteStrictMode,
+		"none":    http.SameSiteNoneMode,
+	}
+	codecs       = []cache.Codec{cache.JsonCodec, cache.GobCodec, cache.MsgpackCodec}
+	compressions = []cache.Compression{cache.NoCompression, cache.GzipCompression, cache.ZstdCompression}
+)
+
+func Load(paths ...string) (Config, error) {
+	var f file
+	for _, path := range paths {
+		if err := f.read(path); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := f.validate(); err != nil {
+		return Config{}, err
+	}
+	return f.build()
+}
+
+func MustLoad(paths ...string) Config {
+	c, err := Load(paths...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func (f *file) read(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &node)
+	case ".toml":
+		var data map[string]any
+		if err = toml.Unmarshal(b, &data); err == nil {
+			err = node.Encode(data)
+		}
+	case ".json":
+		var data any
+		if err = json.Unmarshal(b, &data); err == nil {
+			err = node.Encode(data)
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrorUnsupportedFormat, path)
+	}
+	if err == nil && !node.IsZero() {
+		interpolate(&node)
+		err = node.Decode(f)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func (f *file) validate() error {
+	errs := make([]error, 0)
+	missing := func(value string, field string) {
+		if len(strings.TrimSpace(value)) == 0 {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrorMissingField, field))
+		}
+	}
+	invalid := func(field string, value string) {
+		errs = append(errs, fmt.Errorf("%w: %s: %s", ErrorInvalidField, field, value))
+	}
+	missing(f.App.Name, "app.name")
+	for name, db := range f.Database {
+		missing(db.Driver, "database."+name+".driver")
+		missing(db.Host, "database."+name+".host")
+		missing(db.Dbname, "database."+name+".dbname")
+		missing(db.User, "database."+name+".user")
+	}
+	if f.Cache.Redis != nil {
+		missing(f.Cache.Redis.Addr, "cache.redis.addr")
+	}
+	if len(f.Cache.Codec) > 0 && findCodec(f.Cache.Codec) == nil {
+		invalid("cache.codec", f.Cache.Codec)
+	}
+	if len(f.Cache.Compression) > 0 && findCompression(f.Cache.Compression) == nil {
+		invalid("cache.compression", f.Cache.Compression)
+	}
+	if _, ok := sameSites[strings.ToLower(f.Cookie.SameSite)]; len(f.Cookie.SameSite) > 0 && !ok {
+		invalid("cookie.sameSite", f.Cookie.SameSite)
+	}
+	switch f.Filesystem.Driver {
+	case "":
+	case filesystem.Local:
+		missing(f.Filesystem.Dir, "filesystem.dir")
+	case filesystem.Cloud:
+		missing(f.Filesystem.Name, "filesystem.name")
+		if f.Filesystem.Cloud == nil {
+			missing("", "filesystem.cloud")
+			break
+		}
+		missing(f.Filesystem.Cloud.Endpoint, "filesystem.cloud.endpoint")
+	default:
+		invalid("filesystem.driver", f.Filesystem.Driver)
+	}
+	for i, language := range f.Localization.Languages {
+		missing(language.Code, fmt.Sprintf("localization.languages.%d.code", i))
+	}
+	for i, role := range f.Security.Auth.Roles {
+		missing(role.Name, fmt.Sprintf("security.auth.roles.%d.name", i))
+	}
+	if f.Security.Auth.Mode == auth.SessionModeJwt {
+		if err := auth.ValidateStateless(f.Security.Auth.Stateless); err != nil {
+			errs = append(errs, fmt.Errorf("%w: security.auth.stateless.keys: %w", ErrorInvalidField, err))
+		}
+	}
+	for i, fw := range f.Security.Firewalls {
+		for _, matcher := range fw.Matchers {
+			if _, err := regexp.Compile(matcher); err != nil {
+				invalid(fmt.Sprintf("security.firewalls.%d.matchers", i), matcher)
+			}
+		}
+		for _, role := range fw.Roles {
+			if _, ok := f.findRole(role); !ok {
+				invalid(fmt.Sprintf("security.firewalls.%d.roles", i), role)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f *file) build() (Config, error) {
+	c := Config{
+		App: App{
+			Plugin:         f.App.Plugin,
+			Name:           f.App.Name,
+			PublicUrlPath:  f.App.PublicUrlPath,
+			PublicLocalDir: f.App.PublicLocalDir,
+		},
+		Cache: Cache{
+			Layered:              time.Duration(f.Cache.Layered),
+			Codec:                findCodec(f.Cache.Codec),
+			Compression:          findCompression(f.Cache.Compression),
+			CompressionThreshold: f.Cache.CompressionThreshold,
+		},
+		Cookie: Cookie{
+			Domain:      f.Cookie.Domain,
+			HttpOnly:    f.Cookie.HttpOnly,
+			SameSite:    sameSites[strings.ToLower(f.Cookie.SameSite)],
+			Secure:      f.Cookie.Secure,
+			MaxAge:      f.Cookie.MaxAge,
+			Partitioned: f.Cookie.Partitioned,
+			Prefix:      f.Cookie.Prefix,
+			Sign:        f.Cookie.Sign,
+			Encrypt:     f.Cookie.Encrypt,
+		},
+		Database: make(map[string]*quirk.DB),
+		Export: Export{
+			Gotenberg: Gotenberg{Endpoint: f.Export.Gotenberg.Endpoint},
+		},
+		Filesystem: filesystem.Config{
+			Driver: f.Filesystem.Driver,
+			Name:   f.Filesystem.Name,
+			Dir:    f.Filesystem.Dir,
+		},
+		Localization: Localization{
+			Enabled: f.Localization.Enabled,
+			Path:    f.Localization.Path,
+		},
+		Parser: Parser{Limit: f.Parser.Limit},
+		Router: Router{
+			Prefix: Prefix{
+				Name:   f.Router.Prefix.Name,
+				Proxy:  f.Router.Prefix.Proxy,
+				Cookie: f.Router.Prefix.Cookie,
+			},
+			Recover: f.Router.Recover,
+		},
+		Security: Security{
+			Auth: f.Security.Auth,
+		},
+		Smtp: f.Smtp,
+	}
+	c.Form.Limit = f.Form.Limit
+	if len(f.Router.Prefix.Path) > 0 {
+		c.Router.Prefix.Path = f.Router.Prefix.Path
+	}
+	for _, language := range f.Localization.Languages {
+		c.Localization.Languages = append(c.Localization.Languages, Language{Main: language.Main, Code: language.Code})
+	}
+	if f.Cache.Memory != nil {
+		configs := []memory.Config{memory.MaxEntries(f.Cache.Memory.MaxEntries), memory.MaxBytes(f.Cache.Memory.MaxBytes)}
+		if f.Cache.Memory.Persistence != nil {
+			configs = append(configs, memory.Persistence(*f.Cache.Memory.Persistence))
+		}
+		if f.Cache.Memory.Persistence == nil && len(f.Cache.Memory.Dir) == 0 {
+			configs = append(configs, memory.Persistence(false))
+		}
+		c.Cache.Memory = memory.New(f.Cache.Memory.Dir, configs...)
+	}
+	if f.Cache.Redis != nil {
+		c.Cache.Redis = redis.NewClient(
+			&redis.Options{
+				Addr:     f.Cache.Redis.Addr,
+				Username: f.Cache.Redis.Username,
+				Password: f.Cache.Redis.Password,
+				DB:       f.Cache.Redis.Db,
+			},
+		)
+	}
+	if f.Filesystem.Cloud != nil {
+		client, err := minio.New(
+			f.Filesystem.Cloud.Endpoint, &minio.Options{
+				Creds:  credentials.NewStaticV4(f.Filesystem.Cloud.AccessKey, f.Filesystem.Cloud.SecretKey, ""),
+				Secure: f.Filesystem.Cloud.Secure,
+				Region: f.Filesystem.Cloud.Region,
+			},
+		)
+		if err != nil {
+			return c, err
+		}
+		c.Filesystem.Cloud = client
+	}
+	if f.Security.Csrf != nil {
+		configs := []csrf.Config{csrf.Enabled(f.Security.Csrf.Enabled == nil || *f.Security.Csrf.Enabled)}
+		if f.Security.Csrf.Expiration > 0 {
+			configs = append(configs, csrf.Expiration(time.Duration(f.Security.Csrf.Expiration)))
+		}
+		c.Security.Csrf = csrf.New(configs...)
+	}
+	for _, fw := range f.Security.Firewalls {
+		matchers := make([]*regexp.Regexp, len(fw.Matchers))
+		for i, matcher := range fw.Matchers {
+			matchers[i] = regexp.MustCompile(matcher)
+		}
+		roles := make([]auth.Role, 0, len(fw.Roles))
+		for _, name := range fw.Roles {
+			role, _ := f.findRole(name)
+			roles = append(roles, role)
+		}
+		c.Security.Firewall = append(
+			c.Security.Firewall, *firewall.New(
+				firewall.Enabled(fw.Enabled),
+				firewall.Name(fw.Name),
+				firewall.Groups(fw.Groups...),
+				firewall.Matchers(matchers...),
+				firewall.Paths(fw.Paths...),
+				firewall.Redirect(fw.Redirect),
+				firewall.Roles(roles...),
+				firewall.Secret(fw.Secret),
+			),
+		)
+	}
+	for name, db := range f.Database {
+		configs := []quirk.Config{
+			quirk.WithDriver(db.Driver),
+			quirk.WithHost(db.Host),
+			quirk.WithDbname(db.Dbname),
+			quirk.WithUser(db.User),
+			quirk.WithPassword(db.Password),
+			quirk.WithLog(db.Log),
+		}
+		if db.Port > 0 {
+			configs = append(configs, quirk.WithPort(db.Port))
+		}
+		if len(db.Ssl) > 0 {
+			configs = append(configs, quirk.WithSsl(db.Ssl))
+		}
+		if len(db.CertPath) > 0 {
+			configs = append(configs, quirk.WithCertPath(db.CertPath))
+		}
+		conn, err := quirk.Connect(configs...)
+		if err != nil {
+			return c, fmt.Errorf("database %s: %w", name, err)
+		}
+		c.Database[name] = conn
+	}
+	return c.Init(), nil
+}
+
+func (f *file) findRole(name string) (auth.Role, bool) {
+	for _, role := range f.Security.Auth.Roles {
+		if role.Name == name {
+			return role, true
+		}
+	}
+	return auth.Role{}, false
+}
+
+func interpolate(node *yaml.Node) {
+	for _, item := range node.Content {
+		interpolate(item)
+	}
+	if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!str" {
+		return
+	}
+	if node.Style == 0 && interpolationMatcher.FindString(node.Value) == node.Value {
+		node.Tag = ""
+	}
+	node.Value = interpolationMatcher.ReplaceAllStringFunc(
+		node.Value, func(match string) string {
+			parts := interpolationMatcher.FindStringSubmatch(match)
+			if value, ok := os.LookupEnv(parts[1]); ok {
+				return value
+			}
+			if strings.HasPrefix(parts[2], ":-") {
+				return parts[3]
+			}
+			return ""
+		},
+	)
+}
+
+func findCodec(name string) cache.Codec {
+	for _, codec := range codecs {
+		if codec.Name() == name {
+			return codec
+		}
+	}
+	return nil
+}
+
+func findCompression(name string) cache.Compression {
+	for _, compression := range compressions {
+		if compression.Name() == name {
+			return compression
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	
+	"github.com/stretchr/testify/assert"
+	
+	"github.com/daarlabs/arcanum/cache"
+)
+
+func TestLoad(t *testing.T) {
+	write := func(t *testing.T, name, content string) string {
+		path := filepath.Join(t.TempDir(), name)
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+	t.Run(
+		"yaml", func(t *testing.T) {
+			t.Setenv("TEST_DB_PASSWORD", "secret")
+			path := write(
+				t, "config.yaml", `
+app:
+  name: ${TEST_APP_NAME:-arcanum}
+cache:
+  memory:
+    dir: `+t.TempDir()+`
+    persistence: false
+  codec: msgpack
+  compression: zstd
+cookie:
+  sameSite: lax
+  sign: [key]
+database:
+  main:
+    driver: postgres
+    host: localhost
+    port: 5432
+    dbname: arcanum
+    user: arcanum
+    password: ${TEST_DB_PASSWORD}
+localization:
+  enabled: true
+  languages:
+    - code: cs
+      main: true
+    - code: en
+router:
+  prefix:
+    path: /app
+security:
+  auth:
+    duration: 12h
+    roles:
+      - name: admin
+        super: true
+  csrf:
+    expiration: 30m
+  firewalls:
+    - enabled: true
+      matchers: ["^/admin"]
+      roles: [admin]
+`,
+			)
+			c := MustLoad(path)
+			assert.Equal(t, "arcanum", c.App.Name)
+			assert.NotNil(t, c.Cache.Memory)
+			assert.Equal(t, cache.MsgpackCodec, c.Cache.Codec)
+			assert.Equal(t, cache.ZstdCompression, c.Cache.Compression)
+			assert.Equal(t, http.SameSiteLaxMode, c.Cookie.SameSite)
+			assert.Equal(t, []string{"key"}, c.Cookie.Sign)
+			assert.NotNil(t, c.Database["main"])
+			assert.Len(t, c.Localization.Languages, 2)
+			assert.Equal(t, "/app", c.Router.Prefix.Path)
+			assert.Equal(t, 12*time.Hour, c.Security.Auth.Duration)
+			assert.Equal(t, 30*time.Minute, c.Security.Csrf.GetExpiration())
+			assert.True(t, c.Security.Firewall[0].Match("/admin/users"))
+			assert.Equal(t, "admin", c.Security.Firewall[0].Roles[0].Name)
+			assert.Greater(t, c.Form.Limit, 0)
+		},
+	)
+	t.Run(
+		"layers", func(t *testing.T) {
+			base := write(t, "config.toml", "[app]\nname = \"base\"\n\n[router]\nrecover = true\n")
+			override := write(t, "config.json", `{"app": {"name": "override"}}`)
+			c := MustLoad(base, override)
+			assert.Equal(t, "override", c.App.Name)
+			assert.True(t, c.Router.Recover)
+		},
+	)
+	t.Run(
+		"interpolation", func(t *testing.T) {
+			name := "evil\"\nsecurity:\n  csrf: {expiration: 1m}"
+			t.Setenv("TEST_APP_NAME", name)
+			t.Setenv("TEST_SMTP_PORT", "2525")
+			t.Setenv("TEST_SMTP_USER", "1234")
+			paths := []string{
+				write(
+					t, "config.yaml",
+					"app:\n  name: ${TEST_APP_NAME}\nsmtp:\n  port: ${TEST_SMTP_PORT}\n  user: \"${TEST_SMTP_USER}\"\n",
+				),
+				write(
+					t, "config.json",
+					`{"app": {"name": "${TEST_APP_NAME}"}, "smtp": {"port": "${TEST_SMTP_PORT}", "user": "${TEST_SMTP_USER}"}}`,
+				),
+				write(
+					t, "config.toml",
+					"[app]\nname = \"${TEST_APP_NAME}\"\n\n[smtp]\nport = \"${TEST_SMTP_PORT}\"\nuser = \"${TEST_SMTP_USER}\"\n",
+				),
+			}
+			for _, path := range paths {
+				c := MustLoad(path)
+				assert.Equal(t, name, c.App.Name)
+				assert.Nil(t, c.Security.Csrf)
+				assert.Equal(t, 2525, c.Smtp.Port)
+				assert.Equal(t, "1234", c.Smtp.User)
+			}
+		},
+	)
+	t.Run(
+		"validation", func(t *testing.T) {
+			path := write(
+				t, "config.yml", `
+cache:
+  redis: {}
+  codec: xml
+database:
+  main:
+    driver: postgres
+security:
+  firewalls:
+    - roles: [missing]
+`,
+			)
+			_, err := Load(path)
+			assert.ErrorIs(t, err, ErrorMissingField)
+			assert.ErrorIs(t, err, ErrorInvalidField)
+			for _, field := range []string{"app.name", "cache.redis.addr", "cache.codec", "database.main.host", "security.firewalls.0.roles"} {
+				assert.ErrorContains(t, err, field)
+			}
+			_, err = Load(write(t, "config.ini", ""))
+			assert.ErrorIs(t, err, ErrorUnsupportedFormat)
+		},
+	)
+}
@@ -0,0 +1,169 @@
+package cookie
+
+import (
+	"net/http"
+	"strings"
+)
+
+type Config interface{}
+
+type config struct {
+	name  string
+	value any
+}
+
+type options struct {
+	domain      string
+	path        string
+	httpOnly    bool
+	sameSite    http.SameSite
+	secure      bool
+	maxAge      bool
+	partitioned bool
+	prefix      string
+	signKeys    []string
+	encryptKeys []string
+}
+
+const (
+	configDomain      = "domain"
+	configPath        = "path"
+	configHttpOnly    = "httpOnly"
+	configSameSite    = "sameSite"
+	configSecure      = "secure"
+	configMaxAge      = "maxAge"
+	configPartitioned = "partitioned"
+	configPrefix      = "prefix"
+	configSign        = "sign"
+	configEncrypt     = "encrypt"
+)
+
+const (
+	HostPrefix   = "__Host-"
+	SecurePrefix = "__Secure-"
+)
+
+func Domain(domain string) Config {
+	return &config{
+		name:  configDomain,
+		value: domain,
+	}
+}
+
+func Path(path string) Config {
+	return &config{
+		name:  configPath,
+		value: path,
+	}
+}
+
+func HttpOnly(enabled ...bool) Config {
+	return &config{
+		name:  configHttpOnly,
+		value: isEnabled(enabled...),
+	}
+}
+
+func SameSite(sameSite http.SameSite) Config {
+	return &config{
+		name:  configSameSite,
+		value: sameSite,
+	}
+}
+
+func Secure(enabled ...bool) Config {
+	return &config{
+		name:  configSecure,
+		value: isEnabled(enabled...),
+	}
+}
+
+func MaxAge(enabled ...bool) Config {
+	return &config{
+		name:  configMaxAge,
+		value: isEnabled(enabled...),
+	}
+}
+
+func Partitioned(enabled ...bool) Config {
+	return &config{
+		name:  configPartitioned,
+		value: isEnabled(enabled...),
+	}
+}
+
+func Prefix(prefix string) Config {
+	return &config{
+		name:  configPrefix,
+		value: prefix,
+	}
+}
+
+func Sign(keys ...string) Config {
+	return &config{
+		name:  configSign,
+		value: keys,
+	}
+}
+
+func Encrypt(keys ...string) Config {
+	return &config{
+		name:  configEncrypt,
+		value: keys,
+	}
+}
+
+func (o options) apply(configs ...Config) options {
+	for _, item := range configs {
+		c, ok := item.(*config)
+		if !ok {
+			continue
+		}
+		switch c.name {
+		case configDomain:
+			o.domain = c.value.(string)
+		case configPath:
+			o.path = c.value.(string)
+		case configHttpOnly:
+			o.httpOnly = c.value.(bool)
+		case configSameSite:
+			o.sameSite = c.value.(http.SameSite)
+		case configSecure:
+			o.secure = c.value.(bool)
+		case configMaxAge:
+			o.maxAge = c.value.(bool)
+		case configPartitioned:
+			o.partitioned = c.value.(bool)
+		case configPrefix:
+			o.prefix = c.value.(string)
+		case configSign:
+			o.signKeys = c.value.([]string)
+		case configEncrypt:
+			o.encryptKeys = c.value.([]string)
+		}
+	}
+	switch o.prefix {
+	case HostPrefix:
+		o.secure, o.domain, o.path = true, "", "/"
+	case SecurePrefix:
+		o.secure = true
+	}
+	if o.partitioned {
+		o.secure = true
+	}
+	return o
+}
+
+func (o options) name(name string) string {
+	if len(o.prefix) == 0 || strings.HasPrefix(name, o.prefix) {
+		return name
+	}
+	return o.prefix + name
+}
+
+func isEnabled(enabled ...bool) bool {
+	if len(enabled) > 0 {
+		return enabled[0]
+	}
+	return true
+}
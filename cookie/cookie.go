@@ -1,58 +1,197 @@
 package cookie
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 	
 	"github.com/daarlabs/arcanum/env"
 )
 
 type Cookie interface {
-	Get(name string) string
-	Set(name string, value any, expiration time.Duration)
-	Destroy(name string)
+	Get(name string, configs ...Config) string
+	GetJSON(name string, data any, configs ...Config) error
+	Set(name string, value any, expiration time.Duration, configs ...Config)
+	SetJSON(name string, data any, expiration time.Duration, configs ...Config) error
+	Destroy(name string, configs ...Config)
+	
+	MustGetJSON(name string, data any, configs ...Config)
+	MustSetJSON(name string, data any, expiration time.Duration, configs ...Config)
 }
 
 type cookie struct {
-	req  *http.Request
-	res  http.ResponseWriter
-	path string
+	req     *http.Request
+	res     http.ResponseWriter
+	options options
 }
 
+const (
+	chunkSize      = 4000
+	chunkPrefix    = "$chunks:"
+	chunkDelimiter = "."
+)
+
+const (
+	partitionedAttribute = "Partitioned"
+)
+
 func New(
 	req *http.Request,
 	res http.ResponseWriter,
 	path string,
+	configs ...Config,
 ) Cookie {
 	return &cookie{
-		req:  req,
-		res:  res,
-		path: path,
+		req: req,
+		res: res,
+		options: options{
+			path:     path,
+			sameSite: http.SameSiteStrictMode,
+			secure:   env.Production(),
+		}.apply(configs...),
 	}
 }
 
-func (c cookie) Get(name string) string {
-	r, err := c.req.Cookie(name)
+func (c cookie) Get(name string, configs ...Config) string {
+	value, err := c.get(name, c.options.apply(configs...))
 	if err != nil {
 		return ""
 	}
-	return r.Value
+	return value
+}
+
+func (c cookie) GetJSON(name string, data any, configs ...Config) error {
+	value, err := c.get(name, c.options.apply(configs...))
+	if err != nil {
+		return err
+	}
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return ErrorInvalidValue
+	}
+	return json.Unmarshal(b, data)
+}
+
+func (c cookie) MustGetJSON(name string, data any, configs ...Config) {
+	if err := c.GetJSON(name, data, configs...); err != nil {
+		panic(err)
+	}
+}
+
+func (c cookie) Set(name string, value any, expiration time.Duration, configs ...Config) {
+	if err := c.set(name, fmt.Sprintf("%v", value), expiration, c.options.apply(configs...)); err != nil {
+		panic(err)
+	}
+}
+
+func (c cookie) SetJSON(name string, data any, expiration time.Duration, configs ...Config) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return c.set(name, base64.RawURLEncoding.EncodeToString(b), expiration, c.options.apply(configs...))
+}
+
+func (c cookie) MustSetJSON(name string, data any, expiration time.Duration, configs ...Config) {
+	if err := c.SetJSON(name, data, expiration, configs...); err != nil {
+		panic(err)
+	}
+}
+
+func (c cookie) Destroy(name string, configs ...Config) {
+	o := c.options.apply(configs...)
+	name = o.name(name)
+	c.write(name, "", -1, o)
+	c.clean(name, 0, o)
+}
+
+func (c cookie) get(name string, o options) (string, error) {
+	name = o.name(name)
+	r, err := c.req.Cookie(name)
+	if err != nil {
+		return "", ErrorNotExist
+	}
+	value := r.Value
+	if strings.HasPrefix(value, chunkPrefix) {
+		n, err := strconv.Atoi(strings.TrimPrefix(value, chunkPrefix))
+		if err != nil {
+			return "", ErrorInvalidValue
+		}
+		var b strings.Builder
+		for i := 0; i < n; i++ {
+			chunk, err := c.req.Cookie(createChunkName(name, i))
+			if err != nil {
+				return "", ErrorInvalidValue
+			}
+			b.WriteString(chunk.Value)
+		}
+		value = b.String()
+	}
+	return o.decode(name, value)
 }
 
-func (c cookie) Set(name string, value any, expiration time.Duration) {
-	http.SetCookie(
-		c.res, &http.Cookie{
-			Name:     name,
-			Value:    fmt.Sprintf("%v", value),
-			Path:     c.path,
-			Expires:  time.Now().Add(expiration),
-			Secure:   env.Production(),
-			SameSite: http.SameSiteStrictMode,
-		},
-	)
+func (c cookie) set(name, value string, expiration time.Duration, o options) error {
+	name = o.name(name)
+	value, err := o.encode(name, value)
+	if err != nil {
+		return err
+	}
+	if len(value) <= chunkSize {
+		c.write(name, value, expiration, o)
+		c.clean(name, 0, o)
+		return nil
+	}
+	n := 0
+	for ; n*chunkSize < len(value); n++ {
+		c.write(createChunkName(name, n), value[n*chunkSize:min((n+1)*chunkSize, len(value))], expiration, o)
+	}
+	c.write(name, chunkPrefix+strconv.Itoa(n), expiration, o)
+	c.clean(name, n, o)
+	return nil
+}
+
+func (c cookie) write(name, value string, expiration time.Duration, o options) {
+	r := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Domain:   o.domain,
+		Path:     o.path,
+		HttpOnly: o.httpOnly,
+		Secure:   o.secure,
+		SameSite: o.sameSite,
+	}
+	switch {
+	case expiration < 0:
+		r.MaxAge = -1
+	case o.maxAge:
+		r.MaxAge = max(int(expiration.Seconds()), 1)
+	default:
+		r.Expires = time.Now().Add(expiration)
+	}
+	v := r.String()
+	if len(v) == 0 {
+		return
+	}
+	if o.partitioned {
+		v += "; " + partitionedAttribute
+	}
+	c.res.Header().Add("Set-Cookie", v)
+}
+
+func (c cookie) clean(name string, from int, o options) {
+	for i := from; ; i++ {
+		chunk := createChunkName(name, i)
+		if _, err := c.req.Cookie(chunk); err != nil {
+			return
+		}
+		c.write(chunk, "", -1, o)
+	}
 }
 
-func (c cookie) Destroy(name string) {
-	c.Set(name, "", time.Millisecond)
+func createChunkName(name string, index int) string {
+	return name + chunkDelimiter + strconv.Itoa(index)
 }
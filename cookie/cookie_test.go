@@ -0,0 +1,110 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCookie(t *testing.T) {
+	roundtrip := func(set func(c Cookie), configs ...Config) (Cookie, []*http.Cookie) {
+		res := httptest.NewRecorder()
+		set(New(httptest.NewRequest(http.MethodGet, "/", nil), res, "/", configs...))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		cookies := res.Result().Cookies()
+		for _, item := range cookies {
+			req.AddCookie(item)
+		}
+		return New(req, httptest.NewRecorder(), "/", configs...), cookies
+	}
+	t.Run(
+		"plain", func(t *testing.T) {
+			c, _ := roundtrip(func(c Cookie) { c.Set("test", 123, time.Minute) })
+			assert.Equal(t, "123", c.Get("test"))
+		},
+	)
+	t.Run(
+		"sign", func(t *testing.T) {
+			c, cookies := roundtrip(func(c Cookie) { c.Set("test", "value", time.Minute) }, Sign("new"))
+			assert.Equal(t, "value", c.Get("test"))
+			_, rotated := roundtrip(func(c Cookie) { c.Set("test", "value", time.Minute) }, Sign("old"))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.AddCookie(rotated[0])
+			assert.Equal(t, "value", New(req, httptest.NewRecorder(), "/", Sign("new", "old")).Get("test"))
+			forged := httptest.NewRequest(http.MethodGet, "/", nil)
+			forged.AddCookie(&http.Cookie{Name: "test", Value: "dmFsdWU." + strings.Repeat("a", 43)})
+			assert.Equal(t, "", New(forged, httptest.NewRecorder(), "/", Sign("new")).Get("test"))
+			renamed := httptest.NewRequest(http.MethodGet, "/", nil)
+			renamed.AddCookie(&http.Cookie{Name: "other", Value: cookies[0].Value})
+			assert.Equal(t, "", New(renamed, httptest.NewRecorder(), "/", Sign("new")).Get("other"))
+		},
+	)
+	t.Run(
+		"encrypt", func(t *testing.T) {
+			c, cookies := roundtrip(func(c Cookie) { c.Set("test", "secret", time.Minute) }, Encrypt("new", "old"))
+			assert.NotContains(t, cookies[0].Value, "secret")
+			assert.Equal(t, "secret", c.Get("test"))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.AddCookie(cookies[0])
+			assert.Equal(t, "secret", New(req, httptest.NewRecorder(), "/", Encrypt("newer", "new")).Get("test"))
+			assert.Equal(t, "", New(req, httptest.NewRecorder(), "/", Encrypt("other")).Get("test"))
+		},
+	)
+	t.Run(
+		"json", func(t *testing.T) {
+			type test struct {
+				Name  string `json:"name"`
+				Items []int  `json:"items"`
+			}
+			c, _ := roundtrip(
+				func(c Cookie) { c.MustSetJSON("test", test{Name: "a, b; \"c\"", Items: []int{1, 2}}, time.Minute) },
+				Sign("key"),
+			)
+			var r test
+			c.MustGetJSON("test", &r)
+			assert.Equal(t, test{Name: "a, b; \"c\"", Items: []int{1, 2}}, r)
+			assert.ErrorIs(t, c.GetJSON("missing", &r), ErrorNotExist)
+		},
+	)
+	t.Run(
+		"chunks", func(t *testing.T) {
+			value := strings.Repeat("x", 3*chunkSize)
+			c, cookies := roundtrip(func(c Cookie) { c.Set("test", value, time.Minute) }, Encrypt("key"))
+			assert.Greater(t, len(cookies), 3)
+			for _, item := range cookies {
+				assert.LessOrEqual(t, len(item.Value), chunkSize)
+			}
+			assert.Equal(t, value, c.Get("test"))
+			res := httptest.NewRecorder()
+			New(c.(*cookie).req, res, "/", Encrypt("key")).Set("test", "small", time.Minute)
+			assert.Len(t, res.Result().Cookies(), len(cookies))
+		},
+	)
+	t.Run(
+		"options", func(t *testing.T) {
+			res := httptest.NewRecorder()
+			c := New(httptest.NewRequest(http.MethodGet, "/", nil), res, "/app", HttpOnly(), Domain("example.com"))
+			c.Set("default", "a", time.Minute)
+			c.Set("lax", "b", time.Hour, SameSite(http.SameSiteLaxMode), MaxAge(), Partitioned())
+			c.Set("host", "c", time.Minute, Prefix(HostPrefix))
+			c.Destroy("removed", MaxAge())
+			headers := res.Header().Values("Set-Cookie")
+			assert.Len(t, headers, 4)
+			assert.Contains(t, headers[0], "Path=/app; Domain=example.com; Expires=")
+			assert.Contains(t, headers[0], "HttpOnly; SameSite=Strict")
+			assert.Contains(t, headers[1], "Max-Age=3600; HttpOnly; Secure; SameSite=Lax; Partitioned")
+			assert.NotContains(t, headers[1], "Expires=")
+			assert.True(t, strings.HasPrefix(headers[2], "__Host-host=c; Path=/; Expires="))
+			assert.NotContains(t, headers[2], "Domain=")
+			assert.Contains(t, headers[2], "Secure")
+			assert.Contains(t, headers[3], "Max-Age=0")
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.AddCookie(&http.Cookie{Name: "__Host-host", Value: "c"})
+			assert.Equal(t, "c", New(req, httptest.NewRecorder(), "/", Prefix(HostPrefix)).Get("host"))
+		},
+	)
+}
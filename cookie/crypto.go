@@ -0,0 +1,102 @@
+package cookie
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+const (
+	signatureDelimiter = "."
+)
+
+func (o options) encode(name, value string) (string, error) {
+	switch {
+	case len(o.encryptKeys) > 0:
+		return encrypt(o.encryptKeys[0], name, value)
+	case len(o.signKeys) > 0:
+		value = base64.RawURLEncoding.EncodeToString([]byte(value))
+		return value + signatureDelimiter + sign(o.signKeys[0], name, value), nil
+	}
+	return value, nil
+}
+
+func (o options) decode(name, value string) (string, error) {
+	switch {
+	case len(o.encryptKeys) > 0:
+		for _, key := range o.encryptKeys {
+			if r, err := decrypt(key, name, value); err == nil {
+				return r, nil
+			}
+		}
+		return "", ErrorInvalidValue
+	case len(o.signKeys) > 0:
+		i := strings.LastIndex(value, signatureDelimiter)
+		if i < 0 {
+			return "", ErrorInvalidSignature
+		}
+		value, signature := value[:i], value[i+len(signatureDelimiter):]
+		for _, key := range o.signKeys {
+			if !hmac.Equal([]byte(sign(key, name, value)), []byte(signature)) {
+				continue
+			}
+			r, err := base64.RawURLEncoding.DecodeString(value)
+			if err != nil {
+				return "", ErrorInvalidValue
+			}
+			return string(r), nil
+		}
+		return "", ErrorInvalidSignature
+	}
+	return value, nil
+}
+
+func sign(key, name, value string) string {
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(name + "=" + value))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func encrypt(key, name, value string) (string, error) {
+	gcm, err := createGcm(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(value), []byte(name))), nil
+}
+
+func decrypt(key, name, value string) (string, error) {
+	gcm, err := createGcm(key)
+	if err != nil {
+		return "", err
+	}
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return "", err
+	}
+	if len(b) < gcm.NonceSize() {
+		return "", ErrorInvalidValue
+	}
+	r, err := gcm.Open(nil, b[:gcm.NonceSize()], b[gcm.NonceSize():], []byte(name))
+	if err != nil {
+		return "", err
+	}
+	return string(r), nil
+}
+
+func createGcm(key string) (cipher.AEAD, error) {
+	k := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(k[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,9 @@
+package cookie
+
+import "errors"
+
+var (
+	ErrorNotExist         = errors.New("cookie does not exist")
+	ErrorInvalidSignature = errors.New("invalid cookie signature")
+	ErrorInvalidValue     = errors.New("invalid cookie value")
+)
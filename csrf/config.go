@@ -16,11 +16,12 @@ type config struct {
 }
 
 const (
-	configCache      = "cache"
-	configCookie     = "cookie"
-	configEnabled    = "enabled"
-	configExpiration = "name"
-	configRequest    = "request"
+	configCache       = "cache"
+	configLegacyCache = "legacy-cache"
+	configCookie      = "cookie"
+	configEnabled     = "enabled"
+	configExpiration  = "name"
+	configRequest     = "request"
 )
 
 func Cache(client cache.Client) Config {
@@ -30,6 +31,13 @@ func Cache(client cache.Client) Config {
 	}
 }
 
+func LegacyCache(client cache.Client) Config {
+	return &config{
+		name:  configLegacyCache,
+		value: client,
+	}
+}
+
 func Cookie(cookie cookie.Cookie) Config {
 	return &config{
 		name:  configCookie,
@@ -29,11 +29,12 @@ type Csrf interface {
 }
 
 type csrf struct {
-	Cache      cache.Client
-	Cookie     cookie.Cookie
-	Enabled    bool
-	Expiration time.Duration
-	Request    *http.Request
+	Cache       cache.Client
+	LegacyCache cache.Client
+	Cookie      cookie.Cookie
+	Enabled     bool
+	Expiration  time.Duration
+	Request     *http.Request
 }
 
 type Token struct {
@@ -65,6 +66,8 @@ func New(configs ...Config) Csrf {
 		switch c.name {
 		case configCache:
 			r.Cache = c.value.(cache.Client)
+		case configLegacyCache:
+			r.LegacyCache = c.value.(cache.Client)
 		case configCookie:
 			r.Cookie = c.value.(cookie.Cookie)
 		case configEnabled:
@@ -87,7 +90,11 @@ func (c *csrf) GetExpiration() time.Duration {
 }
 
 func (c *csrf) Exists(name, value string) (bool, error) {
-	ok := c.Cache.Exists(c.createCacheKey(Token{Name: name, Value: value}))
+	key := c.createCacheKey(Token{Name: name, Value: value})
+	ok := c.Cache.Exists(key)
+	if !ok && c.LegacyCache != nil {
+		ok = c.LegacyCache.Exists(key)
+	}
 	if !ok {
 		return ok, ErrorInvalidToken
 	}
@@ -104,9 +111,15 @@ func (c *csrf) MustExists(name, value string) bool {
 
 func (c *csrf) Get(name, value string) (Token, error) {
 	var result Token
-	if err := c.Cache.Get(c.createCacheKey(Token{Name: name, Value: value}), &result); err != nil {
+	key := c.createCacheKey(Token{Name: name, Value: value})
+	if err := c.Cache.Get(key, &result); err != nil {
 		return result, err
 	}
+	if !result.Exists && c.LegacyCache != nil {
+		if err := c.LegacyCache.Get(key, &result); err != nil {
+			return result, err
+		}
+	}
 	if !result.Exists {
 		return result, ErrorInvalidToken
 	}
@@ -144,7 +157,13 @@ func (c *csrf) MustCreate(token Token) string {
 }
 
 func (c *csrf) Destroy(token Token) error {
-	return c.Cache.Destroy(c.createCacheKey(token))
+	key := c.createCacheKey(token)
+	if c.LegacyCache != nil {
+		if err := c.LegacyCache.Destroy(key); err != nil {
+			return err
+		}
+	}
+	return c.Cache.Destroy(key)
 }
 
 func (c *csrf) Clean(ignore string) error {
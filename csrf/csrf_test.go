@@ -54,4 +54,28 @@ func TestCsrf(t *testing.T) {
 			assert.True(t, strings.HasPrefix(res.Header().Get("Set-Cookie"), "X-Csrf-"+name1+"="+token1))
 		},
 	)
+	t.Run(
+		"legacy cache fallback", func(t *testing.T) {
+			path := "/test"
+			name := "test-name"
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			res := httptest.NewRecorder()
+			root := cache.New(context.Background(), memory.New(t.TempDir()), nil)
+			legacy := New(
+				Cache(root),
+				Cookie(cookie.New(req, res, path)),
+			)
+			token := legacy.MustCreate(Token{Name: name})
+			c := New(
+				Cache(root.Namespace("csrf")),
+				LegacyCache(root),
+				Cookie(cookie.New(req, res, path)),
+			)
+			assert.True(t, c.MustExists(name, token))
+			assert.Equal(t, name, c.MustGet(name, token).Name)
+			c.MustDestroy(Token{Name: name, Value: token})
+			ok, _ := c.Exists(name, token)
+			assert.False(t, ok)
+		},
+	)
 }
@@ -0,0 +1,193 @@
+package env
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type tag struct {
+	name       string
+	value      string
+	hasDefault bool
+	required   bool
+}
+
+const (
+	tagName         = "env"
+	tagRequired     = "required"
+	tagDefault      = "default="
+	prefixDelimiter = "_"
+	sliceDelimiter  = ","
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func Bind(target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return ErrorInvalidTarget
+	}
+	return errors.Join(bindStruct(v.Elem(), "")...)
+}
+
+func MustBind(target any) {
+	if err := Bind(target); err != nil {
+		panic(err)
+	}
+}
+
+func bindStruct(v reflect.Value, prefix string) []error {
+	errs := make([]error, 0)
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		raw, ok := field.Tag.Lookup(tagName)
+		if raw == "-" {
+			continue
+		}
+		t := parseTag(raw)
+		nested := prefix
+		if len(t.name) > 0 {
+			nested += t.name + prefixDelimiter
+		}
+		if field.Type.Kind() == reflect.Struct && !isTextUnmarshaler(v.Field(i)) {
+			errs = append(errs, bindStruct(v.Field(i), nested)...)
+			continue
+		}
+		if isStructPtr(field.Type) {
+			p := v.Field(i)
+			if p.IsNil() {
+				p = reflect.New(field.Type.Elem())
+			}
+			errs = append(errs, bindStruct(p.Elem(), nested)...)
+			if v.Field(i).IsNil() && !p.Elem().IsZero() {
+				v.Field(i).Set(p)
+			}
+			continue
+		}
+		if !ok || len(t.name) == 0 {
+			continue
+		}
+		name := prefix + t.name
+		value, exists := os.LookupEnv(name)
+		if !exists {
+			switch {
+			case t.hasDefault:
+				value = t.value
+			case t.required:
+				errs = append(errs, fmt.Errorf("%w: %s", ErrorMissingValue, name))
+				continue
+			default:
+				continue
+			}
+		}
+		if err := setValue(v.Field(i), value); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %w", ErrorInvalidValue, name, err))
+		}
+	}
+	return errs
+}
+
+func parseTag(raw string) tag {
+	parts := strings.Split(raw, ",")
+	t := tag{name: strings.TrimSpace(parts[0])}
+	values := make([]string, 0)
+	for _, part := range parts[1:] {
+		part = strings.TrimSpace(part)
+		switch {
+		case part == tagRequired:
+			t.required = true
+		case strings.HasPrefix(part, tagDefault):
+			t.hasDefault = true
+			values = append(values, strings.TrimPrefix(part, tagDefault))
+		case t.hasDefault:
+			values = append(values, part)
+		}
+	}
+	t.value = strings.Join(values, ",")
+	return t
+}
+
+func setValue(v reflect.Value, value string) error {
+	if isTextUnmarshaler(v) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		items := make([]string, 0)
+		for _, item := range strings.Split(value, sliceDelimiter) {
+			if item = strings.TrimSpace(item); len(item) > 0 {
+				items = append(items, item)
+			}
+		}
+		s := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(s.Index(i), item); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		if err := setValue(p.Elem(), value); err != nil {
+			return err
+		}
+		v.Set(p)
+	default:
+		return ErrorUnsupported
+	}
+	return nil
+}
+
+func isTextUnmarshaler(v reflect.Value) bool {
+	return v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType)
+}
+
+func isStructPtr(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct && !t.Implements(textUnmarshalerType)
+}
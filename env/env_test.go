@@ -0,0 +1,109 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnv(t *testing.T) {
+	t.Run(
+		"load", func(t *testing.T) {
+			dir := t.TempDir()
+			files := map[string]string{
+				".env":            "APP_ENV=staging\nTEST_BASE=base\nTEST_MODE=base\nTEST_LOCAL=base\nTEST_PROCESS=base\n",
+				".env.staging":    "# comment\nexport TEST_MODE=\"mode\\nline\"\nTEST_LOCAL=mode\n",
+				".env.local":      "TEST_LOCAL='local' # comment\n",
+				".env.production": "TEST_MODE=production\n",
+			}
+			for name, content := range files {
+				assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+			}
+			for _, key := range []string{"APP_ENV", "TEST_BASE", "TEST_MODE", "TEST_LOCAL"} {
+				t.Setenv(key, "")
+				assert.NoError(t, os.Unsetenv(key))
+			}
+			t.Setenv("TEST_PROCESS", "process")
+			MustLoad(dir)
+			assert.True(t, Staging())
+			assert.Equal(t, "base", os.Getenv("TEST_BASE"))
+			assert.Equal(t, "mode\nline", os.Getenv("TEST_MODE"))
+			assert.Equal(t, "local", os.Getenv("TEST_LOCAL"))
+			assert.Equal(t, "process", os.Getenv("TEST_PROCESS"))
+		},
+	)
+	t.Run(
+		"bind", func(t *testing.T) {
+			type database struct {
+				Host string `env:"HOST,default=localhost"`
+				Port int    `env:"PORT,default=5432"`
+			}
+			type test struct {
+				Name     string        `env:"TEST_NAME,required"`
+				Debug    bool          `env:"TEST_DEBUG"`
+				Timeout  time.Duration `env:"TEST_TIMEOUT,default=5s"`
+				Hosts    []string      `env:"TEST_HOSTS,default=a,b,required"`
+				Ports    []int         `env:"TEST_PORTS"`
+				Database database      `env:"TEST_DB"`
+				Ignored  string
+			}
+			t.Setenv("TEST_NAME", "arcanum")
+			t.Setenv("TEST_DEBUG", "true")
+			t.Setenv("TEST_PORTS", "80, 443")
+			t.Setenv("TEST_DB_HOST", "db")
+			var r test
+			MustBind(&r)
+			assert.Equal(
+				t, test{
+					Name:     "arcanum",
+					Debug:    true,
+					Timeout:  5 * time.Second,
+					Hosts:    []string{"a", "b"},
+					Ports:    []int{80, 443},
+					Database: database{Host: "db", Port: 5432},
+				}, r,
+			)
+		},
+	)
+	t.Run(
+		"bind errors", func(t *testing.T) {
+			type test struct {
+				First  string        `env:"TEST_MISSING_FIRST,required"`
+				Second string        `env:"TEST_MISSING_SECOND,required"`
+				Wait   time.Duration `env:"TEST_INVALID_WAIT"`
+			}
+			t.Setenv("TEST_INVALID_WAIT", "soon")
+			var r test
+			err := Bind(&r)
+			assert.ErrorIs(t, err, ErrorMissingValue)
+			assert.ErrorIs(t, err, ErrorInvalidValue)
+			assert.ErrorContains(t, err, "TEST_MISSING_FIRST")
+			assert.ErrorContains(t, err, "TEST_MISSING_SECOND")
+			assert.ErrorIs(t, Bind(r), ErrorInvalidTarget)
+		},
+	)
+	t.Run(
+		"bind pointer and spaced tags", func(t *testing.T) {
+			type mailer struct {
+				Host string `env:"HOST, required"`
+				Port int    `env:"PORT, default=25"`
+			}
+			type test struct {
+				Mailer  *mailer `env:"TEST_MAILER"`
+				Missing *mailer `env:"TEST_NONE"`
+			}
+			t.Setenv("TEST_MAILER_HOST", "smtp")
+			t.Setenv("TEST_NONE_HOST", "")
+			assert.NoError(t, os.Unsetenv("TEST_NONE_HOST"))
+			var r test
+			err := Bind(&r)
+			assert.ErrorIs(t, err, ErrorMissingValue)
+			assert.ErrorContains(t, err, "TEST_NONE_HOST")
+			assert.Equal(t, &mailer{Host: "smtp", Port: 25}, r.Mailer)
+			assert.Equal(t, &mailer{Port: 25}, r.Missing)
+		},
+	)
+}
@@ -0,0 +1,11 @@
+package env
+
+import "errors"
+
+var (
+	ErrorInvalidLine   = errors.New("invalid env file line")
+	ErrorInvalidTarget = errors.New("target must be pointer to struct")
+	ErrorInvalidValue  = errors.New("invalid environment variable value")
+	ErrorMissingValue  = errors.New("missing required environment variable")
+	ErrorUnsupported   = errors.New("unsupported field type")
+)
@@ -0,0 +1,109 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	fileName      = ".env"
+	localFileName = ".env.local"
+)
+
+func Load(dir ...string) error {
+	d := "."
+	if len(dir) > 0 {
+		d = dir[0]
+	}
+	values, err := readFile(filepath.Join(d, fileName))
+	if err != nil {
+		return err
+	}
+	mode := Get()
+	if len(mode) == 0 {
+		mode = values[envVar]
+	}
+	files := []string{localFileName}
+	if len(mode) > 0 {
+		files = []string{fileName + "." + mode, localFileName}
+	}
+	for _, name := range files {
+		layer, err := readFile(filepath.Join(d, name))
+		if err != nil {
+			return err
+		}
+		for key, value := range layer {
+			values[key] = value
+		}
+	}
+	for key, value := range values {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func MustLoad(dir ...string) {
+	if err := Load(dir...); err != nil {
+		panic(err)
+	}
+}
+
+func readFile(path string) (map[string]string, error) {
+	result := make(map[string]string)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || len(key) == 0 {
+			return result, fmt.Errorf("%w: %s:%d", ErrorInvalidLine, path, n)
+		}
+		value, err := parseValue(strings.TrimSpace(value))
+		if err != nil {
+			return result, fmt.Errorf("%w: %s:%d", err, path, n)
+		}
+		result[key] = value
+	}
+	return result, scanner.Err()
+}
+
+func parseValue(value string) (string, error) {
+	if len(value) == 0 {
+		return value, nil
+	}
+	quote := value[0]
+	if quote != '"' && quote != '\'' {
+		if i := strings.Index(value, " #"); i > -1 {
+			value = value[:i]
+		}
+		return strings.TrimSpace(value), nil
+	}
+	end := strings.LastIndexByte(value, quote)
+	if end < 1 {
+		return "", ErrorInvalidLine
+	}
+	value = value[1:end]
+	if quote == '"' {
+		value = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(value)
+	}
+	return value, nil
+}
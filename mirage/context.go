@@ -32,6 +32,7 @@ type Ctx interface {
 	Files() filesystem.Client
 	Flash() Flash
 	Generate() Generator
+	Impersonated() bool
 	Lang() Lang
 	Page() Page
 	Parse() parser.Parse
@@ -75,6 +76,12 @@ type ctxParam struct {
 	w                http.ResponseWriter
 }
 
+const (
+	authCacheNamespace  = "auth"
+	csrfCacheNamespace  = "csrf"
+	stateCacheNamespace = "state"
+)
+
 func createContext(p ctxParam) *ctx {
 	cx := context.Background()
 	write := true
@@ -93,10 +100,16 @@ func createContext(p ctxParam) *ctx {
 		write:            &write,
 		parsed:           make(Map),
 	}
-	c.cookie = cookie.New(c.r, c.w, c.createCookiePathBasedOnRouterCookiePrefix())
+	c.cookie = cookie.New(
+		c.r,
+		c.w,
+		c.createCookiePathBasedOnRouterCookiePrefix(),
+		c.config.Cookie.Configs()...,
+	)
 	if c.config.Security.Csrf != nil && c.config.Security.Csrf.IsEnabled() {
 		c.csrf = csrf.New(
-			csrf.Cache(c.Cache()),
+			csrf.Cache(c.Cache().Namespace(csrfCacheNamespace)),
+			csrf.LegacyCache(c.Cache()),
 			csrf.Cookie(c.cookie),
 			csrf.Request(p.r),
 			csrf.Expiration(c.config.Security.Csrf.GetExpiration()),
@@ -110,7 +123,7 @@ func createContext(p ctxParam) *ctx {
 		ctx:    c,
 		route:  p.matchedRoute,
 	}
-	c.state = createState(c.Cache(), c.Cookie())
+	c.state = createState(c.Cache().Namespace(stateCacheNamespace), c.Cookie(), c.Cache())
 	if p.matchedRoute != nil {
 		for _, pathValueKey := range p.matchedRoute.PathValues {
 			c.parsed[pathValueKey] = c.r.PathValue(pathValueKey)
@@ -133,18 +146,28 @@ func (c *ctx) Auth(dbname ...string) auth.Manager {
 			panic(ErrorInvalidDatabase)
 		}
 	}
+	cc := c.Cache()
+	config := c.config.Security.Auth
+	config.LegacyCache = cc
 	return auth.New(
 		db,
 		c.r,
 		c.w,
 		c.cookie,
-		c.Cache(),
-		c.config.Security.Auth,
+		cc.Namespace(authCacheNamespace),
+		config,
 	)
 }
 
 func (c *ctx) Cache() cache.Client {
-	return cache.New(c.Context, c.config.Cache.Memory, c.config.Cache.Redis)
+	return cache.New(
+		c.Context,
+		c.config.Cache.Memory,
+		c.config.Cache.Redis,
+		cache.Layered(c.config.Cache.Layered),
+		cache.Serialize(c.config.Cache.Codec),
+		cache.Compress(c.config.Cache.Compression, c.config.Cache.CompressionThreshold),
+	)
 }
 
 func (c *ctx) Config() config.Config {
@@ -200,6 +223,14 @@ func (c *ctx) Generate() Generator {
 	return &generator{c}
 }
 
+func (c *ctx) Impersonated() bool {
+	session, err := c.Auth().Session().Get()
+	if err != nil {
+		return false
+	}
+	return session.Impersonated()
+}
+
 func (c *ctx) Lang() Lang {
 	return c.lang
 }
@@ -74,6 +74,9 @@ func (h handler) applyInternalMiddlewares(matchedRoute *Route, middlewares []Han
 	if matchedRoute != nil && len(matchedRoute.Firewall) > 0 {
 		r = append(r, createFirewallMiddleware(matchedRoute.Firewall))
 	}
+	if matchedRoute != nil && len(matchedRoute.Permissions) > 0 {
+		r = append(r, createPermissionMiddleware(matchedRoute.Permissions))
+	}
 	r = append(r, middlewares...)
 	return r
 }
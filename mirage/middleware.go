@@ -104,7 +104,7 @@ func createFirewallMiddleware(firewalls []firewall.Firewall) Handler {
 			return c.Response().Error(err)
 		}
 		if session.Super {
-			if err := c.Auth().Session().Renew(); err != nil {
+			if err := c.Auth().Session().Slide(); err != nil {
 				c.Auth().MustOut()
 				return c.Response().Redirect(c.Generate().Current())
 			}
@@ -163,7 +163,7 @@ func createFirewallMiddleware(firewalls []firewall.Firewall) Handler {
 			return c.Response().Error(err)
 		}
 		if allowed {
-			if err := c.Auth().Session().Renew(); err != nil {
+			if err := c.Auth().Session().Slide(); err != nil {
 				c.Auth().MustOut()
 				return c.Response().Redirect(c.Generate().Current())
 			}
@@ -171,3 +171,14 @@ func createFirewallMiddleware(firewalls []firewall.Firewall) Handler {
 		return c.Continue()
 	}
 }
+
+func createPermissionMiddleware(permissions []string) Handler {
+	return func(c Ctx) error {
+		for _, permission := range permissions {
+			if !c.Auth().Can(permission) {
+				return c.Response().Status(http.StatusForbidden).Error(errors.New(http.StatusText(http.StatusForbidden)))
+			}
+		}
+		return c.Continue()
+	}
+}
@@ -0,0 +1,11 @@
+package mirage
+
+import "github.com/daarlabs/arcanum/gox"
+
+func Can(c Ctx, permission string, nodes ...gox.Node) gox.Node {
+	return gox.If(c.Auth().Can(permission), nodes...)
+}
+
+func Cannot(c Ctx, permission string, nodes ...gox.Node) gox.Node {
+	return gox.If(!c.Auth().Can(permission), nodes...)
+}
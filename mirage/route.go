@@ -12,20 +12,22 @@ type RouteConfig struct {
 }
 
 type Route struct {
-	Lang       string
-	Path       string
-	Name       string
-	Layout     layoutFactory
-	Matcher    *regexp.Regexp
-	Methods    []string
-	Firewall   []firewall.Firewall
-	PathValues []string
+	Lang        string
+	Path        string
+	Name        string
+	Layout      layoutFactory
+	Matcher     *regexp.Regexp
+	Methods     []string
+	Firewall    []firewall.Firewall
+	Permissions []string
+	PathValues  []string
 }
 
 const (
 	routeMethod = iota
 	routeName
 	routeLayout
+	routePermission
 )
 
 func Method(method ...string) RouteConfig {
@@ -48,3 +50,10 @@ func Layout(name string) RouteConfig {
 		Value: name,
 	}
 }
+
+func Permission(permission ...string) RouteConfig {
+	return RouteConfig{
+		Type:  routePermission,
+		Value: permission,
+	}
+}
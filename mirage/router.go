@@ -175,6 +175,7 @@ func (r *router) createCanonicalRoute(path string, lang string, config ...RouteC
 func (r *router) createRoute(path string, fn Handler, lang string, config ...RouteConfig) {
 	var name, layout string
 	methods := make([]string, 0)
+	permissions := make([]string, 0)
 	for _, cfg := range config {
 		switch cfg.Type {
 		case routeMethod:
@@ -183,6 +184,8 @@ func (r *router) createRoute(path string, fn Handler, lang string, config ...Rou
 			name = cfg.Value.(string)
 		case routeLayout:
 			layout = cfg.Value.(string)
+		case routePermission:
+			permissions = append(permissions, cfg.Value.([]string)...)
 		}
 	}
 	if len(layout) == 0 {
@@ -209,14 +212,15 @@ func (r *router) createRoute(path string, fn Handler, lang string, config ...Rou
 	matcher, pathValues := r.createMatcher(path)
 	*r.routes = append(
 		*r.routes, &Route{
-			Lang:       lang,
-			Path:       path,
-			Name:       name,
-			Methods:    methods,
-			Layout:     r.core.layout.factories[layout],
-			Matcher:    matcher,
-			PathValues: pathValues,
-			Firewall:   r.createFirewall(path, name),
+			Lang:        lang,
+			Path:        path,
+			Name:        name,
+			Methods:     methods,
+			Layout:      r.core.layout.factories[layout],
+			Matcher:     matcher,
+			PathValues:  pathValues,
+			Firewall:    r.createFirewall(path, name),
+			Permissions: permissions,
 		},
 	)
 	for _, method := range methods {
@@ -39,7 +39,7 @@ var (
 	stateDuration = 7 * 24 * time.Hour
 )
 
-func createState(cache cache.Client, cookie cookie.Cookie) *state {
+func createState(cache cache.Client, cookie cookie.Cookie, legacy ...cache.Client) *state {
 	s := &state{
 		cache:                cache,
 		cookie:               cookie,
@@ -56,6 +56,11 @@ func createState(cache cache.Client, cookie cookie.Cookie) *state {
 	}
 	if s.exists {
 		cache.MustGet(stateCacheKey+":"+s.token, s)
+		if len(legacy) > 0 && legacy[0] != nil && !cache.Exists(stateCacheKey+":"+s.token) && legacy[0].Exists(stateCacheKey+":"+s.token) {
+			legacy[0].MustGet(stateCacheKey+":"+s.token, s)
+			s.mustSave()
+			legacy[0].MustDestroy(stateCacheKey + ":" + s.token)
+		}
 		s.cleanComponents()
 	}
 	return s
@@ -23,6 +23,10 @@ func Open(driverName, dataSourceName string) (*DB, error) {
 	return wrapConnection(db, driverName), err
 }
 
+func Wrap(db *sql.DB, driverName string) *DB {
+	return wrapConnection(db, driverName)
+}
+
 func wrapConnection(db *sql.DB, driverName string) *DB {
 	return &DB{
 		DB:          db,
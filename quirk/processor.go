@@ -70,7 +70,8 @@ func processQueryParts(q *Quirk) (string, []any, error) {
 			isMap := argValueType.Kind() == reflect.Map
 			name := ParamPrefix + partArg.name
 			if !isSafe {
-				p.query = replaceStringAtIndex(p.query, name, fmt.Sprintf("$%d", pgi), findParamIndex(p.query, name))
+				placeholder := createArgPlaceholder(q.driverName, pgi)
+				p.query = replaceStringAtIndex(p.query, name, placeholder, findParamIndex(p.query, name))
 				pgi++
 			}
 			if isSafe {
@@ -103,6 +104,13 @@ func processQueryParts(q *Quirk) (string, []any, error) {
 	return strings.Join(parts, " "), args, nil
 }
 
+func createArgPlaceholder(driverName string, index int) string {
+	if driverName == Mysql {
+		return Placeholder
+	}
+	return fmt.Sprintf("$%d", index)
+}
+
 func transformMapToJsonb(value any) any {
 	switch m := value.(type) {
 	case map[string]string:
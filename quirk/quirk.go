@@ -112,6 +112,28 @@ func (q *Quirk) MustExec(r ...any) {
 	}
 }
 
+func (q *Quirk) Result() (sql.Result, error) {
+	t := time.Now()
+	mergedQueryParts, args, err := processQueryParts(q)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(mergedQueryParts, querySuffix) {
+		mergedQueryParts += querySuffix
+	}
+	r, err := q.DB.Exec(mergedQueryParts, args...)
+	q.afterQuery(t, mergedQueryParts, args)
+	return r, err
+}
+
+func (q *Quirk) MustResult() sql.Result {
+	r, err := q.Result()
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
 func (q *Quirk) exec(result ...any) error {
 	t := time.Now()
 	mergedQueryParts, args, err := processQueryParts(q)
@@ -33,4 +33,18 @@ func TestUtil(t *testing.T) {
 			containsQueryNamedParam(`:Neco::tsquery`)
 		},
 	)
+	t.Run(
+		"driver placeholders", func(t *testing.T) {
+			for driverName, expected := range map[string]string{
+				Postgres: `WHERE id = $1 AND email = $2`,
+				Mysql:    `WHERE id = ? AND email = ?`,
+			} {
+				q := New(wrapConnection(nil, driverName)).Q(`WHERE id = @id AND email = @email`, Map{"id": 1, "email": "a"})
+				query, args, err := processQueryParts(q)
+				assert.NoError(t, err)
+				assert.Equal(t, expected, query)
+				assert.Equal(t, []any{1, "a"}, args)
+			}
+		},
+	)
 }
@@ -12,6 +12,7 @@ import (
 type Config struct {
 	App          config.App
 	Cache        config.Cache
+	Cookie       config.Cookie
 	Database     map[string]*quirk.DB
 	Export       config.Export
 	Filesystem   filesystem.Config
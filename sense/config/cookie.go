@@ -0,0 +1,42 @@
+package config
+
+import (
+	"net/http"
+	
+	"github.com/daarlabs/arcanum/cookie"
+)
+
+type Cookie struct {
+	Domain      string
+	HttpOnly    bool
+	SameSite    http.SameSite
+	Secure      bool
+	MaxAge      bool
+	Partitioned bool
+	Prefix      string
+	Sign        []string
+	Encrypt     []string
+}
+
+func (c Cookie) Configs() []cookie.Config {
+	configs := []cookie.Config{
+		cookie.HttpOnly(c.HttpOnly),
+		cookie.MaxAge(c.MaxAge),
+		cookie.Partitioned(c.Partitioned),
+		cookie.Sign(c.Sign...),
+		cookie.Encrypt(c.Encrypt...),
+	}
+	if len(c.Domain) > 0 {
+		configs = append(configs, cookie.Domain(c.Domain))
+	}
+	if c.SameSite > 0 {
+		configs = append(configs, cookie.SameSite(c.SameSite))
+	}
+	if c.Secure {
+		configs = append(configs, cookie.Secure())
+	}
+	if len(c.Prefix) > 0 {
+		configs = append(configs, cookie.Prefix(c.Prefix))
+	}
+	return configs
+}
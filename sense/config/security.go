@@ -17,7 +17,8 @@ type Csrf struct {
 }
 
 type Firewall struct {
-	Enabled  bool
-	Patterns []string
-	Roles    []string
+	Enabled     bool
+	Patterns    []string
+	Roles       []string
+	Permissions []string
 }
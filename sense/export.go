@@ -1,7 +1,7 @@
 package sense
 
 import (
-	"exporter"
+	"github.com/daarlabs/arcanum/exporter"
 	
 	"github.com/daarlabs/arcanum/sense/config"
 )
@@ -39,6 +39,7 @@ type handlerContext struct {
 	config  Config
 	res     http.ResponseWriter
 	req     *http.Request
+	bearer  bool
 	mu      *sync.Mutex
 	cookie  cookie.Cookie
 	files   filesystem.Client
@@ -48,6 +49,10 @@ type handlerContext struct {
 	send    *sender
 }
 
+const (
+	authCacheNamespace = "auth"
+)
+
 func createHandlerContext(args handlerContextArgs) *handlerContext {
 	ctx := context.Background()
 	hc := &handlerContext{
@@ -55,8 +60,14 @@ func createHandlerContext(args handlerContextArgs) *handlerContext {
 		config:  args.config,
 		res:     args.res,
 		req:     args.req,
+		bearer:  args.bearer,
 		mu:      &sync.Mutex{},
-		cookie:  cookie.New(args.req, args.res, formatPath(args.config.Router.Prefix)+"/"),
+		cookie: cookie.New(
+			args.req,
+			args.res,
+			formatPath(args.config.Router.Prefix)+"/",
+			args.config.Cookie.Configs()...,
+		),
 		files:   filesystem.New(ctx, args.config.Filesystem),
 		parse:   &parser{req: args.req, limit: args.config.Parser.Limit},
 		request: &request{req: args.req},
@@ -86,18 +97,29 @@ func (c *handlerContext) Auth(dbname ...string) auth.Manager {
 			panic(ErrorInvalidDatabase)
 		}
 	}
+	cc := c.Cache()
+	config := c.config.Security.Auth
+	config.LegacyCache = cc
+	config.Bearer = c.bearer
 	return auth.New(
 		db,
 		c.req,
 		c.res,
 		c.cookie,
-		c.Cache(),
-		c.config.Security.Auth,
+		cc.Namespace(authCacheNamespace),
+		config,
 	)
 }
 
 func (c *handlerContext) Cache() cache.Client {
-	return cache.New(c.Context, c.config.Cache.Memory, c.config.Cache.Redis)
+	return cache.New(
+		c.Context,
+		c.config.Cache.Memory,
+		c.config.Cache.Redis,
+		cache.Layered(c.config.Cache.Layered),
+		cache.Serialize(c.config.Cache.Codec),
+		cache.Compress(c.config.Cache.Compression, c.config.Cache.CompressionThreshold),
+	)
 }
 
 func (c *handlerContext) Config() Config {
@@ -23,6 +23,7 @@ func createHandlerFunc(args handlerFuncArgs) func(
 				config: args.config,
 				req:    req,
 				res:    res,
+				bearer: len(args.route.Firewalls) > 0,
 			},
 		)
 		if args.config.Router.Recover {
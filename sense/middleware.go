@@ -18,27 +18,34 @@ func authMiddleware(firewalls []config.Firewall) Handler {
 		if err != nil || session.Id == 0 {
 			return c.Send().Status(http.StatusForbidden).Error(errors.New(http.StatusText(http.StatusForbidden)))
 		}
-		allowed := session.Super
-		if !session.Super {
+		allowed := session.Super && session.Scopes == nil
+		if !allowed {
 			for _, f := range firewalls {
-				if len(f.Roles) == 0 {
-					allowed = true
+				if session.Scopes != nil && len(f.Permissions) == 0 {
 					continue
 				}
-				if slices.ContainsFunc(
+				if !session.Super && len(f.Roles) > 0 && !slices.ContainsFunc(
 					f.Roles, func(firewallRole string) bool {
 						return slices.Contains(session.Roles, firewallRole)
 					},
 				) {
-					allowed = true
+					continue
+				}
+				if slices.ContainsFunc(
+					f.Permissions, func(permission string) bool {
+						return !session.Can(permission)
+					},
+				) {
+					continue
 				}
+				allowed = true
 			}
 		}
 		if !allowed {
 			return c.Send().Status(http.StatusForbidden).Error(errors.New(http.StatusText(http.StatusForbidden)))
 		}
-		if allowed {
-			if err := c.Auth().Session().Renew(); err != nil {
+		if allowed && len(session.Token) > 0 {
+			if err := c.Auth().Session().Slide(); err != nil {
 				return c.Send().Status(http.StatusInternalServerError).Error(err)
 			}
 		}
@@ -46,6 +53,17 @@ func authMiddleware(firewalls []config.Firewall) Handler {
 	}
 }
 
+func Permission(permissions ...string) Handler {
+	return func(c Context) error {
+		for _, permission := range permissions {
+			if !c.Auth().Can(permission) {
+				return c.Send().Status(http.StatusForbidden).Error(errors.New(http.StatusText(http.StatusForbidden)))
+			}
+		}
+		return c.Continue()
+	}
+}
+
 func trailingSlashMiddleware() Handler {
 	return func(c Context) error {
 		path := c.Request().Path()
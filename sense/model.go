@@ -32,4 +32,5 @@ type handlerContextArgs struct {
 	req    *http.Request
 	res    http.ResponseWriter
 	ws     map[string]socketer.Ws
+	bearer bool
 }
@@ -49,7 +49,8 @@ func New(config Config) Translator {
 func (t *translator) Extend(langCode string, locales map[string]string) Translator {
 	langTranslates, ok := t.translates[langCode]
 	if !ok {
-		t.translates[langCode] = make(map[string]string)
+		langTranslates = make(map[string]string)
+		t.translates[langCode] = langTranslates
 	}
 	for k, v := range locales {
 		langTranslates[k] = v